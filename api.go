@@ -6,9 +6,34 @@ type Client interface {
 	Read(string) (interface{}, error)
 	Write(string, interface{}) error
 	MultiRead(...string) (map[string]interface{}, error)
+	ReadBoolArray(string, int) ([]bool, error)
+	WriteBoolArray(string, []bool) error
+	WriteMasked(string, uint64, uint64) error
+	WriteBits(string, map[int]bool) error
 	GetPLCTime() (time.Time, error)
 	SetPLCTime(time.Time) error
+	GetPLCTimeZone() (PLCTimeZone, error)
+	SetPLCTimeZone(PLCTimeZone) error
+	GetPLCLocalTime() (time.Time, error)
+	SetPLCLocalTime(time.Time) error
 	GetTagList() ([]Tag, error)
-	Discover()
+	ListPrograms() ([]string, error)
+	ListTags(string, bool) ([]Tag, error)
+	GetTemplate(uint16) (*Template, error)
+	SaveTagCache(string) error
+	LoadTagCache(string) error
+	ProjectChanged() (bool, error)
+	Discover(string, time.Duration) ([]Identity, error)
+	ListServices() ([]Service, error)
+	ListInterfaces() ([]Interface, error)
+	GetIdentity() (Identity, error)
+	GetModuleIdentity(int) (Identity, error)
+	RackScan(int) ([]SlotInfo, error)
+	GetControllerStatus() (ControllerStatus, error)
+	GenericMessage(uint8, uint16, uint32, uint16, []byte) ([]byte, MessageStatus, error)
+	GenericUnconnectedMessage(uint8, uint16, uint32, uint16, []byte, []byte) ([]byte, MessageStatus, error)
+	SetContextGenerator(ContextGenerator)
+	LastContext() uint64
+	Health() error
 	Stop()
 }
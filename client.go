@@ -10,7 +10,10 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"go_eip/epath"
 )
 
 type CIPType struct {
@@ -22,23 +25,30 @@ type Option struct {
 	VendorID               uint16
 	SessionHandle          uint32
 	ProcessorSlot          uint8
-	ContextPointer         uint8
 	SerialNumber           uint16
 	OriginatorSerialNumber uint32
 	OTNetworkConnectionID  uint32
+	TONetworkConnectionID  uint32
 	SequenceCounter        uint16
-	Offset                 uint32
+	InstanceAddressing     bool
+	ProjectCheckInterval   time.Duration
+	KeepAliveInterval      time.Duration
+	KeepAliveMode          KeepAliveMode
+	KeepAliveMaxFailures   int
 }
 
 var GlobalOption = Option{
 	VendorID:               1,
 	ProcessorSlot:          0,
 	SessionHandle:          0x0000,
-	ContextPointer:         0,
 	SerialNumber:           0,
 	OriginatorSerialNumber: 42,
 	SequenceCounter:        1,
-	Offset:                 0,
+	InstanceAddressing:     false,
+	ProjectCheckInterval:   10 * time.Second,
+	KeepAliveInterval:      0,
+	KeepAliveMode:          KeepAliveConnected,
+	KeepAliveMaxFailures:   3,
 }
 
 var cipTypeMap = map[uint8]CIPType{
@@ -54,168 +64,9 @@ var cipTypeMap = map[uint8]CIPType{
 	201: {8, "LWORD"},
 	202: {4, "REAL"},
 	203: {8, "LREAL"},
-	//211: {4, "DWORD"},
-}
-var contextMap = map[uint8]uint64{
-	0:   0x6572276557,
-	1:   0x6f6e,
-	2:   0x676e61727473,
-	3:   0x737265,
-	4:   0x6f74,
-	5:   0x65766f6c,
-	6:   0x756f59,
-	7:   0x776f6e6b,
-	8:   0x656874,
-	9:   0x73656c7572,
-	10:  0x646e61,
-	11:  0x6f73,
-	12:  0x6f64,
-	13:  0x49,
-	14:  0x41,
-	15:  0x6c6c7566,
-	16:  0x74696d6d6f63,
-	17:  0x7327746e656d,
-	18:  0x74616877,
-	19:  0x6d2749,
-	20:  0x6b6e696874,
-	21:  0x676e69,
-	22:  0x666f,
-	23:  0x756f59,
-	24:  0x746e646c756f77,
-	25:  0x746567,
-	26:  0x73696874,
-	27:  0x6d6f7266,
-	28:  0x796e61,
-	29:  0x726568746f,
-	30:  0x797567,
-	31:  0x49,
-	32:  0x7473756a,
-	33:  0x616e6e6177,
-	34:  0x6c6c6574,
-	35:  0x756f79,
-	36:  0x776f68,
-	37:  0x6d2749,
-	38:  0x676e696c656566,
-	39:  0x6174746f47,
-	40:  0x656b616d,
-	41:  0x756f79,
-	42:  0x7265646e75,
-	43:  0x646e617473,
-	44:  0x726576654e,
-	45:  0x616e6e6f67,
-	46:  0x65766967,
-	47:  0x756f79,
-	48:  0x7075,
-	49:  0x726576654e,
-	50:  0x616e6e6f67,
-	51:  0x74656c,
-	52:  0x756f79,
-	53:  0x6e776f64,
-	54:  0x726576654e,
-	55:  0x616e6e6f67,
-	56:  0x6e7572,
-	57:  0x646e756f7261,
-	58:  0x646e61,
-	59:  0x747265736564,
-	60:  0x756f79,
-	61:  0x726576654e,
-	62:  0x616e6e6f67,
-	63:  0x656b616d,
-	64:  0x756f79,
-	65:  0x797263,
-	66:  0x726576654e,
-	67:  0x616e6e6f67,
-	68:  0x796173,
-	69:  0x657962646f6f67,
-	70:  0x726576654e,
-	71:  0x616e6e6f67,
-	72:  0x6c6c6574,
-	73:  0x61,
-	74:  0x65696c,
-	75:  0x646e61,
-	76:  0x74727568,
-	77:  0x756f79,
-	78:  0x6576276557,
-	79:  0x6e776f6e6b,
-	80:  0x68636165,
-	81:  0x726568746f,
-	82:  0x726f66,
-	83:  0x6f73,
-	84:  0x676e6f6c,
-	85:  0x72756f59,
-	86:  0x73277472616568,
-	87:  0x6e656562,
-	88:  0x676e69686361,
-	89:  0x747562,
-	90:  0x657227756f59,
-	91:  0x6f6f74,
-	92:  0x796873,
-	93:  0x6f74,
-	94:  0x796173,
-	95:  0x7469,
-	96:  0x656469736e49,
-	97:  0x6577,
-	98:  0x68746f62,
-	99:  0x776f6e6b,
-	100: 0x732774616877,
-	101: 0x6e656562,
-	102: 0x676e696f67,
-	103: 0x6e6f,
-	104: 0x6557,
-	105: 0x776f6e6b,
-	106: 0x656874,
-	107: 0x656d6167,
-	108: 0x646e61,
-	109: 0x6572276577,
-	110: 0x616e6e6f67,
-	111: 0x79616c70,
-	112: 0x7469,
-	113: 0x646e41,
-	114: 0x6669,
-	115: 0x756f79,
-	116: 0x6b7361,
-	117: 0x656d,
-	118: 0x776f68,
-	119: 0x6d2749,
-	120: 0x676e696c656566,
-	121: 0x74276e6f44,
-	122: 0x6c6c6574,
-	123: 0x656d,
-	124: 0x657227756f79,
-	125: 0x6f6f74,
-	126: 0x646e696c62,
-	127: 0x6f74,
-	128: 0x656573,
-	129: 0x726576654e,
-	130: 0x616e6e6f67,
-	131: 0x65766967,
-	132: 0x756f79,
-	133: 0x7075,
-	134: 0x726576654e,
-	135: 0x616e6e6f67,
-	136: 0x74656c,
-	137: 0x756f79,
-	138: 0x6e776f64,
-	139: 0x726576654e,
-	140: 0x6e7572,
-	141: 0x646e756f7261,
-	142: 0x646e61,
-	143: 0x747265736564,
-	144: 0x756f79,
-	145: 0x726576654e,
-	146: 0x616e6e6f67,
-	147: 0x656b616d,
-	148: 0x756f79,
-	149: 0x797263,
-	150: 0x726576654e,
-	151: 0x616e6e6f67,
-	152: 0x796173,
-	153: 0x657962646f6f67,
-	154: 0x726576654e,
-	155: 0xa680e2616e6e6f67,
+	211: {4, "DWORD"},
 }
-var knownTags = make(map[string]uint8)
-var ProgramNames = make(map[string]string)
+var sequenceMu sync.Mutex
 
 type ClientHandler interface {
 	Packager
@@ -225,16 +76,57 @@ type ClientHandler interface {
 type client struct {
 	packager    Packager
 	transporter Transporter
+
+	// tag types, instances and templates learned from this controller
+	knownTags        map[string]uint8
+	knownInstances   map[string]uint32
+	knownSymbolTypes map[string]uint16
+	knownTemplates   map[uint16]*Template
+	signature        []byte
+	lastProjectCheck time.Time
+
+	// connection IDs Forward Open assigned to this client's connection
+	otConnectionID uint32
+	toConnectionID uint32
+	// connectionTimeout is how long the target keeps the connection without
+	// traffic, from the API Forward Open granted.
+	connectionTimeout time.Duration
+
+	contextMu        sync.Mutex
+	contextGenerator ContextGenerator
+	lastContext      uint64
+
+	// mu serializes exchanges on the transporter between callers and the
+	// keep-alive.
+	mu            sync.Mutex
+	lastRequest   time.Time
+	lastConnected time.Time
+	keepAlive     chan struct{}
+	keepAliveDone chan struct{}
+
+	healthMu  sync.Mutex
+	failures  int
+	healthErr error
 }
 
-type Tag struct {
-	TagName  string
-	Offset   uint16
-	DataType uint8
+const boolArrayMaxWords = 100
+
+const symbolClass = 0x6B
+
+var messageRouterPath = epath.Join(epath.Class(0x02), epath.Instance(1))
+var connectionManagerPath = epath.Join(epath.Class(0x06), epath.Instance(1))
+
+// backplanePort is the port of a 1756 chassis backplane.
+const backplanePort = 1
+
+// backplaneRoute is the route to the module in slot of the chassis.
+func backplaneRoute(slot uint8) []byte {
+	return epath.Port(backplanePort, []byte{slot})
 }
 
 func NewClient(handler ClientHandler, slot int) Client {
-	c := &client{packager: handler, transporter: handler}
+	c := &client{packager: handler, transporter: handler, contextGenerator: NewContextGenerator()}
+	c.clearTagCache()
 	GlobalOption.ProcessorSlot = uint8(slot)
 
 	resp, err := c.transporter.Send(c.BuildRegisterSessionRequest())
@@ -242,52 +134,86 @@ func NewClient(handler ClientHandler, slot int) Client {
 		c.transporter.Close()
 		return nil
 	}
-	var sessionHandle, networkCID uint32
-	binary.Read(bytes.NewBuffer(resp[4:8]), binary.LittleEndian, &sessionHandle)
-	GlobalOption.SessionHandle = sessionHandle
+	header, _, err := ParseEncapsulation(resp)
+	if err != nil {
+		c.transporter.Close()
+		return nil
+	}
+	GlobalOption.SessionHandle = header.SessionHandle
 
 	resp, err = c.transporter.Send(c.BuildForwardOpenRequest())
 	if err != nil {
 		c.transporter.Close()
 		return nil
 	}
-	binary.Read(bytes.NewBuffer(resp[44:48]), binary.LittleEndian, &networkCID)
-	GlobalOption.OTNetworkConnectionID = networkCID
+	data, _, err := cipReplyData(resp)
+	if err != nil || len(data) < 8 {
+		c.transporter.Close()
+		return nil
+	}
+	c.otConnectionID = binary.LittleEndian.Uint32(data[0:4])
+	c.toConnectionID = binary.LittleEndian.Uint32(data[4:8])
+	GlobalOption.OTNetworkConnectionID = c.otConnectionID
+	GlobalOption.TONetworkConnectionID = c.toConnectionID
+	if len(data) >= 20 {
+		c.connectionTimeout = connectionTimeout(binary.LittleEndian.Uint32(data[16:20]))
+	}
+	c.lastConnected = time.Now()
 
+	if err := c.startKeepAlive(); err != nil {
+		log.Println(err)
+		c.transporter.Close()
+		return nil
+	}
 	return c
 }
 
 func (c *client) Read(tag string) (interface{}, error) {
-	dataType, e := c.getDataType(tag)
+	path, e := ParseTagPath(tag)
 	if e != nil {
 		return nil, e
 	}
-
-	tagSplit := strings.Split(tag, ".")
-	var requestData []byte
-	if pos, err := strconv.Atoi(tagSplit[len(tagSplit)-1]); err == nil {
-		words := (pos + 1) / int(c.getByteCount(dataType).ByteCount*8)
-		if (pos + 1) > 32 {
-			words += 1
-		}
-		requestData = c.BuildReadIOIRequest(tag, false, words)
-	} else {
-		requestData = c.BuildReadIOIRequest(tag, false, 1)
+	dataType, e := c.getDataType(path)
+	if e != nil {
+		return nil, e
 	}
 
+	requestData := c.BuildReadIOIRequest(path, dataType == 211, 1)
 	response, err := c.send(NewProtocolDataUnit(requestData))
 	if err != nil {
 		return nil, err
 	}
-	status := c.getStatus(response.Data)
-	if status != 0 && status != 6 {
-		return nil, errors.New(ErrorText(int(status)))
+	data, status, err := cipReplyData(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	if !status.OK() {
+		return nil, errors.New(ErrorText(int(status.General)))
 	}
 
-	return c.ParseOutput(tag, response.Data[50:])
+	return c.ParseOutput(path, data)
 }
 func (c *client) Write(tag string, value interface{}) error {
-	request := c.BuildWriteIOIRequest(tag, value)
+	path, e := ParseTagPath(tag)
+	if e != nil {
+		return e
+	}
+	dataType, e := c.getDataType(path)
+	if e != nil {
+		return e
+	}
+	if path.HasBit() && (!isIntegerType(dataType) || path.Bit >= int(cipTypeMap[dataType].ByteCount)*8) {
+		return fmt.Errorf("eip: bit %d is out of range for %s", path.Bit, path.Last().Name)
+	}
+	var request []byte
+	// 160 is any structure; only string structures can be written whole
+	if dataType == 160 {
+		if request, e = c.BuildWriteStringRequest(path, value); e != nil {
+			return e
+		}
+	} else if request, e = c.BuildWriteIOIRequest(path, value); e != nil {
+		return e
+	}
 	response, err := c.send(NewProtocolDataUnit(request))
 	if err != nil {
 		log.Println(err)
@@ -303,107 +229,226 @@ func (c *client) Write(tag string, value interface{}) error {
 func (c *client) MultiRead(tags ...string) (map[string]interface{}, error) {
 	reply := make(map[string]interface{})
 
-	req := c.BuildMultiReadRequest(tags...)
+	paths := make([]*TagPath, len(tags))
+	for i, tag := range tags {
+		path, e := ParseTagPath(tag)
+		if e != nil {
+			return reply, e
+		}
+		// BOOL array elements are read as the DWORD holding the bit
+		isBoolArray, e := c.isBoolArrayElement(path)
+		if e != nil {
+			return reply, e
+		}
+		if isBoolArray {
+			c.knownTags[path.String()] = 211
+		}
+		paths[i] = path
+	}
+
+	req := c.BuildMultiReadRequest(paths...)
 	response, err := c.send(NewProtocolDataUnit(req))
 	if err != nil {
 		return reply, err
 	}
-	status := c.getStatus(response.Data)
-	if status != 0 {
-		return reply, errors.New(ErrorText(int(status)))
+	data, replyStatus, err := cipReplyData(response.Data)
+	if err != nil {
+		return reply, err
+	}
+	if replyStatus.General != 0 {
+		return reply, errors.New(ErrorText(int(replyStatus.General)))
 	}
 
-	stripped := response.Data[52:]
+	if len(data) < 2+2*len(tags) {
+		return reply, errors.New("eip: multiple service reply is truncated")
+	}
+	// offsets count from the service count, so each points at its status
+	stripped := data[2:]
 	for i, tag := range tags {
-		var offset uint16
-		var extend uint8
-		binary.Read(bytes.NewBuffer(stripped[i*2:i*2+2]), binary.LittleEndian, &offset)
-		binary.Read(bytes.NewBuffer(stripped[offset:offset+1]), binary.LittleEndian, &status)
-		binary.Read(bytes.NewBuffer(stripped[offset+1:offset+2]), binary.LittleEndian, &extend)
+		offset := int(binary.LittleEndian.Uint16(stripped[i*2 : i*2+2]))
+		if offset+2 > len(stripped) {
+			return reply, fmt.Errorf("eip: reply offset for %s is out of range", tag)
+		}
+		status, extend := stripped[offset], stripped[offset+1]
 
 		if status != 0 || extend != 0 {
 			return reply, errors.New(ErrorText(int(status)))
 		}
 
-		v, _ := c.ParseOutput(tag, stripped[offset+2:])
+		v, err := c.ParseOutput(paths[i], stripped[offset+2:])
+		if err != nil {
+			return reply, err
+		}
 		reply[tag] = v
 	}
 
 	return reply, nil
 }
-func (c *client) GetPLCTime() (time.Time, error) {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.LittleEndian, struct {
-		AttributeService      uint8
-		AttributeSize         uint8
-		AttributeClassType    uint8
-		AttributeClass        uint8
-		AttributeInstanceType uint8
-		AttributeInstance     uint8
-		AttributeCount        uint16
-		TimeAttribute         uint16
-	}{
-		0x03,
-		0x02,
-		0x20,
-		0x8B,
-		0x24,
-		0x01,
-		0x01,
-		0x0B,
-	})
 
-	eipHeader := c.BuildEIPHeader(buf.Bytes())
-	response, err := c.send(NewProtocolDataUnit(eipHeader))
-	if err != nil {
-		return time.Time{}, err
+// isBoolArrayElement reports whether path addresses one bit of a BOOL array.
+// The tag list and templates answer that without a request; only tags the
+// client has not seen in a tag list need a partial read.
+func (c *client) isBoolArrayElement(path *TagPath) (bool, error) {
+	if len(path.Last().Indices) != 1 || path.HasBit() {
+		return false, nil
+	}
+	if t, ok := c.knownTags[path.String()]; ok {
+		return t == 211, nil
+	}
+	if symbolType, ok := c.resolveSymbolType(path); ok {
+		return symbolType&symbolTypeStruct == 0 && uint8(symbolType) == 211, nil
 	}
-	var plcTimeMSOffset uint64
-	binary.Read(bytes.NewBuffer(response.Data[56:64]), binary.LittleEndian, &plcTimeMSOffset)
-	originTime := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	return originTime.Add(time.Microsecond * time.Duration(plcTimeMSOffset)), nil
+	t, err := c.getDataType(path)
+	return t == 211, err
 }
-func (c *client) SetPLCTime(t time.Time) error {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.LittleEndian, struct {
-		AttributeService      uint8
-		AttributeSize         uint8
-		AttributeClassType    uint8
-		AttributeClass        uint8
-		AttributeInstanceType uint8
-		AttributeInstance     uint8
-		AttributeCount        uint16
-		TimeAttribute         uint16
-		Time                  uint64
-	}{
-		0x04,
-		0x02,
-		0x20,
-		0x8B,
-		0x24,
-		0x01,
-		0x01,
-		0x06,
-		uint64(time.Now().UnixNano()) / 1e3,
-	})
-	eipHeader := c.BuildEIPHeader(buf.Bytes())
-	_, err := c.send(NewProtocolDataUnit(eipHeader))
+func (c *client) ReadBoolArray(tag string, count int) ([]bool, error) {
+	path, e := c.parseBoolArray(tag)
+	if e != nil {
+		return nil, e
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("eip: cannot read %d elements of %s", count, tag)
+	}
+	start := path.Last().Indices[0]
+	values := make([]bool, 0, count)
+
+	for len(values) < count {
+		bit := start + len(values)
+		words := (bit%32 + count - len(values) + 31) / 32
+		if words > boolArrayMaxWords {
+			words = boolArrayMaxWords
+		}
+		request := c.BuildReadIOIRequest(path.Element(bit), true, words)
+		response, err := c.send(NewProtocolDataUnit(request))
+		if err != nil {
+			return values, err
+		}
+		data, status, err := cipReplyData(response.Data)
+		if err != nil {
+			return values, err
+		}
+		if status.General != 0 {
+			return values, errors.New(ErrorText(int(status.General)))
+		}
+		if len(data) < 2+words*4 || data[0] != 211 {
+			return values, errors.New("eip: " + path.Last().Name + " is not a BOOL array")
+		}
+		for i := 0; i < words; i++ {
+			word := binary.LittleEndian.Uint32(data[2+i*4 : 6+i*4])
+			for pos := bit % 32; pos < 32 && len(values) < count; pos++ {
+				values = append(values, (word>>uint(pos))&1 == 1)
+			}
+			bit = (bit/32 + 1) * 32
+		}
+	}
+
+	return values, nil
+}
+func (c *client) WriteBoolArray(tag string, values []bool) error {
+	path, e := c.parseBoolArray(tag)
+	if e != nil {
+		return e
+	}
+	start := path.Last().Indices[0]
+
+	for i := 0; i < len(values); {
+		bit := start + i
+		var orMask, andMask uint32 = 0, 0xFFFFFFFF
+		for pos := bit % 32; pos < 32 && i < len(values); pos++ {
+			if values[i] {
+				orMask |= 1 << uint(pos)
+			} else {
+				andMask &^= 1 << uint(pos)
+			}
+			i++
+		}
+
+		tagIOI := c.buildTagIOI(path.Element(bit), true)
+		request := c.BuildEIPHeader(c.buildWriteMaskIOT(tagIOI, 4, uint64(orMask), uint64(andMask)))
+		response, err := c.send(NewProtocolDataUnit(request))
+		if err != nil {
+			return err
+		}
+		if status := c.getStatus(response.Data); status != 0 {
+			return errors.New(ErrorText(int(status)))
+		}
+	}
+
+	return nil
+}
+func (c *client) WriteMasked(tag string, orMask, andMask uint64) error {
+	path, byteCount, e := c.maskedTag(tag)
+	if e != nil {
+		return e
+	}
+	width := uint(byteCount * 8)
+	if byteCount < 8 && orMask>>width != 0 {
+		return fmt.Errorf("eip: OR mask %#x does not fit %s", orMask, tag)
+	}
+	// an AND mask is either sized to the tag or starts from all ones; any
+	// other high bits would clear bits the tag does not have
+	if upper := andMask >> width; byteCount < 8 && upper != 0 && upper != math.MaxUint64>>width {
+		return fmt.Errorf("eip: AND mask %#x does not fit %s", andMask, tag)
+	}
+	return c.writeMask(path, byteCount, orMask, andMask)
+}
+func (c *client) WriteBits(tag string, bits map[int]bool) error {
+	path, byteCount, e := c.maskedTag(tag)
+	if e != nil {
+		return e
+	}
+	var orMask, andMask uint64 = 0, math.MaxUint64
+	for bit, value := range bits {
+		if bit < 0 || bit >= byteCount*8 {
+			return fmt.Errorf("eip: bit %d is out of range for %s", bit, tag)
+		}
+		if value {
+			orMask |= 1 << uint(bit)
+		} else {
+			andMask &^= 1 << uint(bit)
+		}
+	}
+	return c.writeMask(path, byteCount, orMask, andMask)
+}
+
+// maskedTag resolves the integer tag a masked write addresses and its width
+// in bytes.
+func (c *client) maskedTag(tag string) (*TagPath, int, error) {
+	path, e := ParseTagPath(tag)
+	if e != nil {
+		return nil, 0, e
+	}
+	if path.HasBit() {
+		return nil, 0, errors.New("eip: masked writes address the whole word, not " + tag)
+	}
+	dataType, e := c.getDataType(path)
+	if e != nil {
+		return nil, 0, e
+	}
+	if !isIntegerType(dataType) {
+		return nil, 0, errors.New("eip: masked writes need an integer tag, " + tag + " is " + cipTypeMap[dataType].TypeName)
+	}
+	return path, int(cipTypeMap[dataType].ByteCount), nil
+}
+func (c *client) writeMask(path *TagPath, byteCount int, orMask, andMask uint64) error {
+	request := c.BuildEIPHeader(c.buildWriteMaskIOT(c.buildTagIOI(path, false), byteCount, orMask, andMask))
+	response, err := c.send(NewProtocolDataUnit(request))
 	if err != nil {
 		return err
 	}
+	if status := c.getStatus(response.Data); status != 0 {
+		return errors.New(ErrorText(int(status)))
+	}
 	return nil
 }
 func (c *client) GetTagList() ([]Tag, error) {
-	tagList := make([]Tag, 0)
-
-	tList, e := c._getTagList("")
+	tagList, e := c._getTagList("")
 	if e != nil {
 		log.Println(e)
 		return tagList, e
 	}
-	tagList = append(tagList, tList...)
 
-	for p := range ProgramNames {
+	for _, p := range programNames(tagList) {
 		tList, e := c._getTagList(p)
 		if e != nil {
 			log.Println(e)
@@ -414,57 +459,76 @@ func (c *client) GetTagList() ([]Tag, error) {
 
 	return tagList, nil
 }
-func (c *client) Discover() {}
+func (c *client) ListPrograms() ([]string, error) {
+	tagList, e := c._getTagList("")
+	if e != nil {
+		return nil, e
+	}
+	return programNames(tagList), nil
+}
+func (c *client) ListTags(scope string, filterSystem bool) ([]Tag, error) {
+	if scope != "" && !strings.HasPrefix(scope, programPrefix) {
+		scope = programPrefix + scope
+	}
+	if len(scope) > epath.MaxSymbolLength {
+		return nil, fmt.Errorf("eip: program scope %s is longer than %d characters", scope, epath.MaxSymbolLength)
+	}
+	tagList, e := c._getTagList(scope)
+	if e != nil {
+		return nil, e
+	}
+
+	tags := make([]Tag, 0, len(tagList))
+	for _, t := range tagList {
+		if t.IsProgram() || (filterSystem && t.IsSystem()) {
+			continue
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
 func (c *client) Stop() {
+	c.stopKeepAlive()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.transporter.Send(c.BuildForwardCloseRequest())
-	c.transporter.Send(c.BuildUnregisterSessionRequest())
+	// the target closes the socket instead of answering UnregisterSession
+	if p, ok := c.transporter.(poster); ok {
+		p.Post(c.BuildUnregisterSessionRequest())
+	} else {
+		c.transporter.Send(c.BuildUnregisterSessionRequest())
+	}
 	c.transporter.Close()
 }
 
+func isIntegerType(dataType uint8) bool {
+	return dataType >= 194 && dataType <= 201
+}
 func (c *client) getByteCount(s uint8) CIPType {
 	if cip, ok := cipTypeMap[s]; ok {
 		return cip
 	}
 	return CIPType{}
 }
-func (c *client) getStatus(data []byte) (status uint8) {
-	if len(data) < 48 {
-		return 0xFF
-	}
-	if e := binary.Read(bytes.NewBuffer(data[48:49]), binary.LittleEndian, &status); e != nil {
+func (c *client) getStatus(data []byte) uint8 {
+	message, err := cipMessage(data)
+	if err != nil || len(message) < 3 {
 		return 0xFF
 	}
-	return
+	return message[2]
 }
-func (c *client) TagNameParser(tag string, offset int) (string, string, int) {
-	base := tag
-	ind := 0
-	if strings.HasSuffix(tag, "]") {
-		pos := strings.LastIndex(tag, "[")
-		base = tag[:pos]
-		temp := tag[pos:]
-		s := strings.Split(temp[1:len(temp)-1], ",")
-		if len(s) == 1 {
-			ind, _ = strconv.Atoi(temp[1 : len(temp)-1])
-		} else {
-
-		}
-	} else {
-		tagSplit := strings.Split(tag, ".")
-		if v, e := strconv.ParseInt(tagSplit[len(tagSplit)-1], 10, 8); e == nil {
-			return tag, strings.Join(tagSplit[:len(tagSplit)-1], "."), int(v)
-		}
 
-		return tag, base, ind
-	}
-	return tag, base, ind
+// nextSequence hands out connected sequence counts; the keep-alive builds
+// requests alongside the caller's.
+func nextSequence() uint16 {
+	sequenceMu.Lock()
+	defer sequenceMu.Unlock()
+	seq := GlobalOption.SequenceCounter
+	GlobalOption.SequenceCounter = (seq + 1) % 10000
+	return seq
 }
 func (c *client) BuildEIPHeader(tagIOI []byte) []byte {
 	buf := new(bytes.Buffer)
-
-	if GlobalOption.ContextPointer == 155 {
-		GlobalOption.ContextPointer = 0
-	}
 	binary.Write(buf, binary.LittleEndian, struct {
 		EIPCommand         uint16
 		EIPLength          uint16
@@ -486,20 +550,18 @@ func (c *client) BuildEIPHeader(tagIOI []byte) []byte {
 		22 + uint16(len(tagIOI)),
 		GlobalOption.SessionHandle,
 		0,
-		contextMap[GlobalOption.ContextPointer],
+		c.nextContext(),
 		0,
 		0,
 		0,
 		2,
 		0xA1,
 		4,
-		GlobalOption.OTNetworkConnectionID,
+		c.otConnectionID,
 		0xB1,
 		uint16(len(tagIOI)) + 2,
-		GlobalOption.SequenceCounter,
+		nextSequence(),
 	})
-	GlobalOption.SequenceCounter += 1
-	GlobalOption.SequenceCounter = GlobalOption.SequenceCounter % 10000
 
 	buf.Write(tagIOI)
 
@@ -521,7 +583,7 @@ func (c *client) BuildRegisterSessionRequest() []byte {
 		0x0004,
 		0,
 		0,
-		0,
+		c.nextContext(),
 		0,
 		1,
 		0,
@@ -539,21 +601,25 @@ func (c *client) BuildUnregisterSessionRequest() []byte {
 		EIPOptions       uint32
 	}{
 		0x66, 0x00, GlobalOption.SessionHandle,
-		0x00, contextMap[GlobalOption.ContextPointer], 0x00,
+		0x00, c.nextContext(), 0x00,
 	})
 	return buf.Bytes()
 }
+
+// forwardOpenMultiplier selects a connection timeout of 32 times the RPI.
+const forwardOpenMultiplier = 3
+
+func connectionTimeout(api uint32) time.Duration {
+	return time.Duration(api) * time.Microsecond * (4 << forwardOpenMultiplier)
+}
+
 func (c *client) BuildForwardOpenRequest() []byte {
 	rand.Seed(time.Now().UnixNano())
 	forwardOpenBuf := new(bytes.Buffer)
 	GlobalOption.SerialNumber = uint16(rand.Intn(65000))
+	binary.Write(forwardOpenBuf, binary.LittleEndian, struct{ a, b uint8 }{0x54, uint8(len(connectionManagerPath) / 2)})
+	forwardOpenBuf.Write(connectionManagerPath)
 	binary.Write(forwardOpenBuf, binary.LittleEndian, struct {
-		CIPService                       uint8
-		CIPPathSize                      uint8
-		CIPClassType                     uint8
-		CIPClass                         uint8
-		CIPInstanceType                  uint8
-		CIPInstance                      uint8
 		CIPPriority                      uint8
 		CIPTimeoutTicks                  uint8
 		CIPOTConnectionID                uint32
@@ -568,12 +634,6 @@ func (c *client) BuildForwardOpenRequest() []byte {
 		CIPTONetworkConnectionParameters int16
 		CIPTransportTrigger              uint8
 	}{
-		0x54,
-		0x02,
-		0x20,
-		0x06,
-		0x24,
-		0x01,
 		0x0A,
 		0x0E,
 		0x20000002,
@@ -581,14 +641,14 @@ func (c *client) BuildForwardOpenRequest() []byte {
 		GlobalOption.SerialNumber,
 		GlobalOption.VendorID,
 		GlobalOption.OriginatorSerialNumber,
-		0x03,
+		forwardOpenMultiplier,
 		0x00201234,
 		0x43f4,
 		0x00204001,
 		0x43f4,
 		0xA3,
 	})
-	connectionPath := []byte{0x01, GlobalOption.ProcessorSlot, 0x20, 0x02, 0x24, 0x01}
+	connectionPath := epath.Join(backplaneRoute(GlobalOption.ProcessorSlot), messageRouterPath)
 	forwardOpenBuf.Write(append([]byte{uint8(len(connectionPath) / 2)}, connectionPath...))
 
 	dH := c.buildEIPSendRRDataHeader(len(forwardOpenBuf.Bytes()))
@@ -600,32 +660,22 @@ func (c *client) BuildForwardCloseRequest() []byte {
 	rand.Seed(time.Now().UnixNano())
 	forwardCloseBuf := new(bytes.Buffer)
 	GlobalOption.SerialNumber = uint16(rand.Intn(65000))
+	binary.Write(forwardCloseBuf, binary.LittleEndian, struct{ a, b uint8 }{0x4E, uint8(len(connectionManagerPath) / 2)})
+	forwardCloseBuf.Write(connectionManagerPath)
 	binary.Write(forwardCloseBuf, binary.LittleEndian, struct {
-		CIPService                uint8
-		CIPPathSize               uint8
-		CIPClassType              uint8
-		CIPClass                  uint8
-		CIPInstanceType           uint8
-		CIPInstance               uint8
 		CIPPriority               uint8
 		CIPTimeoutTicks           uint8
 		CIPConnectionSerialNumber uint16
 		CIPVendorID               uint16
 		CIPOriginatorSerialNumber uint32
 	}{
-		0x4E,
-		0x02,
-		0x20,
-		0x06,
-		0x24,
-		0x01,
 		0x0A,
 		0x0E,
 		GlobalOption.SerialNumber,
 		GlobalOption.VendorID,
 		GlobalOption.OriginatorSerialNumber,
 	})
-	connectionPath := []byte{0x01, GlobalOption.ProcessorSlot, 0x20, 0x02, 0x24, 0x01}
+	connectionPath := epath.Join(backplaneRoute(GlobalOption.ProcessorSlot), messageRouterPath)
 	forwardCloseBuf.Write(append([]byte{uint8(len(connectionPath) / 2)}, connectionPath...))
 
 	dH := c.buildEIPSendRRDataHeader(len(forwardCloseBuf.Bytes()))
@@ -633,29 +683,38 @@ func (c *client) BuildForwardCloseRequest() []byte {
 	buf.Write(append(dH, forwardCloseBuf.Bytes()...))
 	return buf.Bytes()
 }
-func (c *client) BuildTagListRequest(programName string) []byte {
+func (c *client) BuildUnconnectedSendRequest(service uint8, path []byte, data []byte, route []byte) []byte {
+	request := new(bytes.Buffer)
+	binary.Write(request, binary.LittleEndian, struct{ a, b uint8 }{service, uint8(len(path) / 2)})
+	request.Write(path)
+	request.Write(data)
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x52, uint8(len(connectionManagerPath) / 2)})
+	buf.Write(connectionManagerPath)
+	binary.Write(buf, binary.LittleEndian, struct {
+		CIPPriority     uint8
+		CIPTimeoutTicks uint8
+		CIPRequestSize  uint16
+	}{0x0A, 0x0E, uint16(request.Len())})
+	buf.Write(request.Bytes())
+	if request.Len()%2 != 0 {
+		buf.WriteByte(0x00)
+	}
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{uint8(len(route) / 2), 0x00})
+	buf.Write(route)
+
+	return append(c.buildEIPSendRRDataHeader(buf.Len()), buf.Bytes()...)
+}
+func (c *client) BuildTagListRequest(programName string, instance uint32) []byte {
 	buf := new(bytes.Buffer)
 	pathSegment := new(bytes.Buffer)
 	attributes := new(bytes.Buffer)
 
 	if programName != "" {
-		binary.Write(pathSegment, binary.LittleEndian, struct{ H, L uint8 }{0x91, uint8(len(programName))})
-		pathSegment.Write([]byte(programName))
-		if len(programName)%2 != 0 {
-			pathSegment.Write([]byte{0x00})
-		}
-	}
-	binary.Write(pathSegment, binary.LittleEndian, struct{ H uint16 }{0x6B20})
-
-	if GlobalOption.Offset < 256 {
-		binary.Write(pathSegment, binary.LittleEndian, struct{ H, L uint8 }{
-			0x24, uint8(GlobalOption.Offset),
-		})
-	} else {
-		binary.Write(pathSegment, binary.LittleEndian, struct{ H, L uint16 }{
-			0x25, uint16(GlobalOption.Offset),
-		})
+		pathSegment.Write(epath.Symbol(programName))
 	}
+	pathSegment.Write(epath.Join(epath.Class(symbolClass), epath.Instance(instance)))
 
 	binary.Write(buf, binary.LittleEndian, struct {
 		Service        uint8
@@ -668,31 +727,37 @@ func (c *client) BuildTagListRequest(programName string) []byte {
 		AttributeCount uint16
 		SymbolType     uint16
 		ByteCount      uint16
+		Dimensions     uint16
+		ExternalAccess uint16
 		SymbolName     uint16
-	}{03, 02, 07, 01})
+	}{5, 2, 7, 8, 10, 1})
 	buf.Write(pathSegment.Bytes())
 	buf.Write(attributes.Bytes())
 
 	return c.BuildEIPHeader(buf.Bytes())
 }
-func (c *client) BuildPartialReadRequest(tag string) []byte {
-	tagIOI := c.buildTagIOI(tag, false)
+func (c *client) BuildPartialReadRequest(path *TagPath, isBoolArray bool) []byte {
+	tagIOI := c.buildTagIOI(path, isBoolArray)
 
 	buf := new(bytes.Buffer)
 	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x52, uint8(len(tagIOI) / 2)})
 	binary.Write(buf, binary.LittleEndian, tagIOI)
-	binary.Write(buf, binary.LittleEndian, struct{ a, b, c uint16 }{uint16(1), uint16(GlobalOption.Offset), uint16(0)})
+	binary.Write(buf, binary.LittleEndian, struct {
+		Elements uint16
+		Offset   uint32
+	}{1, 0})
 
 	return c.BuildEIPHeader(buf.Bytes())
 }
-func (c *client) BuildReadIOIRequest(tag string, isBoolArray bool, elements int) []byte {
-	tagIOI := c.buildTagIOI(tag, isBoolArray)
+func (c *client) BuildReadIOIRequest(path *TagPath, isBoolArray bool, elements int) []byte {
+	tagIOI := c.buildTagIOI(path, isBoolArray)
 	return c.BuildEIPHeader(c.buildReadIOI(tagIOI, elements))
 }
-func (c *client) BuildMultiReadRequest(tags ...string) []byte {
+func (c *client) BuildMultiReadRequest(tags ...*TagPath) []byte {
 	buf := new(bytes.Buffer)
 
-	binary.Write(buf, binary.LittleEndian, []uint8{0x0a, 0x02, 0x20, 0x02, 0x24, 0x01})
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x0A, uint8(len(messageRouterPath) / 2)})
+	buf.Write(messageRouterPath)
 	offset := len(buf.Bytes())
 	if len(tags) > 2 {
 		offset += (len(tags) - 2) * 2
@@ -703,7 +768,7 @@ func (c *client) BuildMultiReadRequest(tags ...string) []byte {
 
 	segments := make([][]byte, 0)
 	for _, tag := range tags {
-		tI := c.buildReadIOI(c.buildTagIOI(tag, false), 1)
+		tI := c.buildReadIOI(c.buildTagIOI(tag, c.knownTags[tag.String()] == 211), 1)
 		segments = append(segments, tI)
 	}
 
@@ -718,19 +783,53 @@ func (c *client) BuildMultiReadRequest(tags ...string) []byte {
 
 	return c.BuildEIPHeader(buf.Bytes())
 }
-func (c *client) BuildWriteIOIRequest(tag string, value interface{}) []byte {
+func (c *client) BuildWriteIOIRequest(path *TagPath, value interface{}) ([]byte, error) {
 	buf := new(bytes.Buffer)
-	tagData := c.buildTagIOI(tag, false)
-	tagSplit := strings.Split(tag, ".")
-	if _, e := strconv.ParseInt(tagSplit[len(tagSplit)-1], 10, 8); e == nil {
-		if v, ok := value.(bool); ok {
-			binary.Write(buf, binary.LittleEndian, c.buildWriteBitIOT(tag, tagData, v, knownTags[tag]))
+	dataType := c.knownTags[path.String()]
+	if dataType == 211 && len(path.Last().Indices) != 1 {
+		return nil, fmt.Errorf("eip: %s is a BOOL array, write one element such as %s[0] or use WriteBoolArray", path, path.Last().Name)
+	}
+	tagData := c.buildTagIOI(path, dataType == 211)
+	if dataType == 211 || path.HasBit() {
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("eip: %s is a bit, cannot write %T", path, value)
 		}
+		binary.Write(buf, binary.LittleEndian, c.buildWriteBitIOT(path, tagData, v, dataType))
 	} else {
-		binary.Write(buf, binary.LittleEndian, c.buildWriteIOT(tagData, value, knownTags[tag]))
+		binary.Write(buf, binary.LittleEndian, c.buildWriteIOT(tagData, value, dataType))
 	}
 
-	return c.BuildEIPHeader(buf.Bytes())
+	return c.BuildEIPHeader(buf.Bytes()), nil
+}
+func (c *client) BuildWriteStringRequest(path *TagPath, value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("eip: %s is a string, cannot write %T", path, value)
+	}
+	st, err := c.getStringType(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(str) > st.Capacity {
+		return nil, fmt.Errorf("eip: %d characters do not fit %s, which holds %d", len(str), path, st.Capacity)
+	}
+
+	tagIOI := c.buildTagIOI(path, false)
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x4d, uint8(len(tagIOI) / 2)})
+	binary.Write(buf, binary.LittleEndian, tagIOI)
+	binary.Write(buf, binary.LittleEndian, struct {
+		a, b uint8
+		c, d uint16
+	}{160, 0x02, st.Handle, 1})
+
+	structure := make([]byte, st.Size)
+	binary.LittleEndian.PutUint32(structure[st.LenOffset:], uint32(len(str)))
+	copy(structure[st.DataOffset:], str)
+	buf.Write(structure)
+
+	return c.BuildEIPHeader(buf.Bytes()), nil
 }
 func (c *client) buildWriteIOT(tagIOI []byte, value interface{}, dataType uint8) []byte {
 	buf := new(bytes.Buffer)
@@ -738,26 +837,12 @@ func (c *client) buildWriteIOT(tagIOI []byte, value interface{}, dataType uint8)
 	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x4d, uint8(len(tagIOI) / 2)})
 	binary.Write(buf, binary.LittleEndian, tagIOI)
 
-	if dataType == 160 {
-		binary.Write(buf, binary.LittleEndian, struct {
-			a, b uint8
-			c, d uint16
-		}{dataType, 0x02, 0x0FCE, 1})
-	} else {
-		binary.Write(buf, binary.LittleEndian, struct {
-			a, b uint8
-			c    uint16
-		}{dataType, 0x0, 1})
-	}
+	binary.Write(buf, binary.LittleEndian, struct {
+		a, b uint8
+		c    uint16
+	}{dataType, 0x0, 1})
 
 	switch dataType {
-	case 160:
-		binary.Write(buf, binary.LittleEndian, struct{ uint32 }{uint32(len(value.(string)))})
-		binary.Write(buf, binary.LittleEndian, []byte(value.(string)))
-		if len(value.(string)) < 84 {
-			binary.Write(buf, binary.LittleEndian, make([]byte, 84-len(value.(string))))
-		}
-
 	case 193:
 		v, e := strconv.ParseBool(fmt.Sprintf("%v", value))
 		if e != nil {
@@ -797,87 +882,71 @@ func (c *client) buildWriteIOT(tagIOI []byte, value interface{}, dataType uint8)
 
 	return buf.Bytes()
 }
-func (c *client) buildWriteBitIOT(tag string, tagIOI []byte, value bool, dataType uint8) []byte {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x4e, uint8(len(tagIOI) / 2)})
-	binary.Write(buf, binary.LittleEndian, tagIOI)
-
-	var bit int
-	bitCount := cipTypeMap[dataType].ByteCount
-	tagSplit := strings.Split(tag, ".")
+func (c *client) buildWriteBitIOT(path *TagPath, tagIOI []byte, value bool, dataType uint8) []byte {
+	bit := path.Bit
 	if dataType == 211 {
-		tag, _, bit = c.TagNameParser(tagSplit[len(tagSplit)-1], 0)
-		bit %= 32
-	} else {
-		bit, _ = strconv.Atoi(tagSplit[len(tagSplit)-1])
+		bit = path.Last().Indices[0] % 32
 	}
-	binary.Write(buf, binary.LittleEndian, struct{ a int16 }{int16(bitCount)})
 
-	b := math.Pow(float64(2), float64(bitCount*8)) - 1
-	bits := math.Pow(float64(2), float64(bit))
-	if !value {
-		b -= bits
-		bits = 0
-	}
-	switch bitCount {
-	case 2:
-		binary.Write(buf, binary.LittleEndian, struct{ a uint16 }{uint16(bits)})
-		binary.Write(buf, binary.LittleEndian, struct{ a uint16 }{uint16(b)})
-	case 4:
-		binary.Write(buf, binary.LittleEndian, struct{ a uint32 }{uint32(bits)})
-		binary.Write(buf, binary.LittleEndian, struct{ a uint32 }{uint32(b)})
-	case 8:
-		binary.Write(buf, binary.LittleEndian, struct{ a uint64 }{uint64(bits)})
-		binary.Write(buf, binary.LittleEndian, struct{ a uint64 }{uint64(b)})
+	var orMask, andMask uint64 = 0, math.MaxUint64
+	if value {
+		orMask = 1 << uint(bit)
+	} else {
+		andMask &^= 1 << uint(bit)
 	}
+	return c.buildWriteMaskIOT(tagIOI, int(cipTypeMap[dataType].ByteCount), orMask, andMask)
+}
+func (c *client) buildWriteMaskIOT(tagIOI []byte, byteCount int, orMask, andMask uint64) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x4e, uint8(len(tagIOI) / 2)})
+	binary.Write(buf, binary.LittleEndian, tagIOI)
+	binary.Write(buf, binary.LittleEndian, struct{ a int16 }{int16(byteCount)})
+
+	mask := make([]byte, 8)
+	binary.LittleEndian.PutUint64(mask, orMask)
+	buf.Write(mask[:byteCount])
+	binary.LittleEndian.PutUint64(mask, andMask)
+	buf.Write(mask[:byteCount])
 
 	return buf.Bytes()
 }
-func (c *client) buildTagIOI(tagName string, isBoolArray bool) []byte {
+func (c *client) buildTagIOI(path *TagPath, isBoolArray bool) []byte {
 	buf := new(bytes.Buffer)
-	tagSplit := strings.Split(tagName, ".")
-	for i, ts := range tagSplit {
-		if strings.HasSuffix(ts, "]") {
-			_, baseTag, index := c.TagNameParser(ts, 0)
-			baseTagLenBytes := len(baseTag)
-
-			if isBoolArray && i == len(tagSplit)-1 {
-				index = index / 32
-			}
-			binary.Write(buf, binary.LittleEndian, struct{ H, L uint8 }{0x91, uint8(baseTagLenBytes)})
-			binary.Write(buf, binary.LittleEndian, []byte(baseTag))
-			if baseTagLenBytes%2 != 0 {
-				baseTagLenBytes += 1
-				binary.Write(buf, binary.LittleEndian, []byte{0x0})
-			}
-			if i < len(tagSplit) {
-				if index < 256 {
-					binary.Write(buf, binary.LittleEndian, struct{ H, L uint8 }{0x28, uint8(index)})
-				}
-				if index > 255 && index < 65536 {
-					binary.Write(buf, binary.LittleEndian, struct{ H, L uint16 }{0x29, uint16(index)})
-				}
-				if index > 65535 {
-					binary.Write(buf, binary.LittleEndian, struct {
-						H uint16
-						L uint32
-					}{0x2A, uint32(index)})
-				}
-			}
-		} else {
-			if _, err := strconv.ParseInt(ts, 10, 8); err != nil {
-				baseTagLenBytes := len(ts)
-				binary.Write(buf, binary.LittleEndian, struct{ H, L uint8 }{0x91, uint8(baseTagLenBytes)})
-				binary.Write(buf, binary.LittleEndian, []byte(ts))
-				if baseTagLenBytes%2 != 0 {
-					baseTagLenBytes += 1
-					binary.Write(buf, binary.LittleEndian, []byte{0x0})
-				}
+	if path.Program != "" {
+		buf.Write(epath.Symbol(programPrefix + path.Program))
+	}
+	instance, useInstance := c.symbolInstance(path)
+	symbolType, typed := c.knownSymbolTypes[path.Symbol()]
+	typed = typed && useInstance
+	for i, ts := range path.Segments {
+		member := -1
+		if i > 0 && typed {
+			member, symbolType, typed = c.knownMember(symbolType, ts.Name)
+		}
+		switch {
+		case i == 0 && useInstance:
+			buf.Write(epath.Join(epath.Class(symbolClass), epath.Instance(instance)))
+		case member >= 0:
+			buf.Write(epath.Member(uint32(member)))
+		default:
+			buf.Write(epath.Symbol(ts.Name))
+		}
+		for j, index := range ts.Indices {
+			if isBoolArray && i == len(path.Segments)-1 && j == len(ts.Indices)-1 {
+				index /= 32
 			}
+			buf.Write(epath.Member(uint32(index)))
 		}
 	}
 	return buf.Bytes()
 }
+func (c *client) symbolInstance(path *TagPath) (uint32, bool) {
+	if !GlobalOption.InstanceAddressing {
+		return 0, false
+	}
+	instance, ok := c.knownInstances[path.Symbol()]
+	return instance, ok
+}
 func (c *client) buildReadIOI(tagIOI []byte, elements int) []byte {
 	buf := new(bytes.Buffer)
 	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{0x4c, uint8(len(tagIOI) / 2)})
@@ -906,7 +975,7 @@ func (c *client) buildEIPSendRRDataHeader(frameLen int) []byte {
 		16 + uint16(frameLen),
 		GlobalOption.SessionHandle,
 		0x00,
-		contextMap[GlobalOption.ContextPointer],
+		c.nextContext(),
 		0x00,
 		0x00,
 		0x00,
@@ -921,72 +990,86 @@ func (c *client) buildEIPSendRRDataHeader(frameLen int) []byte {
 
 func (c *client) parseTag(packet []byte, programName string) (Tag, error) {
 	tag := Tag{}
-	var length uint16
-	if e := binary.Read(bytes.NewBuffer(packet[8:10]), binary.LittleEndian, &length); e != nil {
+	var header tagListEntry
+	if e := binary.Read(bytes.NewBuffer(packet), binary.LittleEndian, &header); e != nil {
 		return tag, e
 	}
+	if len(packet) < tagListEntrySize+int(header.NameLength) {
+		return tag, errors.New("eip: tag list entry is truncated")
+	}
 
-	tag.TagName = string(packet[10 : length+10])
+	tag.TagName = string(packet[tagListEntrySize : tagListEntrySize+int(header.NameLength)])
 	if programName != "" {
 		tag.TagName = programName + "." + tag.TagName
 	}
-	if e := binary.Read(bytes.NewBuffer(packet[:2]), binary.LittleEndian, &tag.Offset); e != nil {
-		return tag, e
-	}
-	if e := binary.Read(bytes.NewBuffer(packet[4:5]), binary.LittleEndian, &tag.DataType); e != nil {
-		log.Println(e)
-	}
+	tag.InstanceID = header.InstanceID
+	tag.DataType = uint8(header.SymbolType)
+	tag.SymbolType = header.SymbolType
+	tag.ElementSize = header.ElementSize
+	tag.Dimensions = header.Dimensions
+	tag.ExternalAccess = header.ExternalAccess
 
 	return tag, nil
 }
 func (c *client) ExtractTagPacket(data []byte, programName string) ([]Tag, error) {
-	var packetStart uint16 = 50
-	var tagLen uint16
+	entries, _, err := cipReplyData(data)
+	if err != nil {
+		return nil, err
+	}
 	tagList := make([]Tag, 0)
 
-	for int(packetStart) < len(data) {
-		if e := binary.Read(bytes.NewBuffer(data[packetStart+8:packetStart+10]), binary.LittleEndian, &tagLen); e != nil {
-			return tagList, e
+	for len(entries) > 0 {
+		if len(entries) < tagListEntrySize {
+			return tagList, errors.New("eip: tag list entry is truncated")
 		}
-		packet := data[packetStart : packetStart+tagLen+10]
-		var offset uint16
-		if e := binary.Read(bytes.NewBuffer(packet[:2]), binary.LittleEndian, &offset); e != nil {
-			return tagList, e
+		tagLen := int(binary.LittleEndian.Uint16(entries[tagListEntrySize-2 : tagListEntrySize]))
+		tag, err := c.parseTag(entries, programName)
+		if err != nil {
+			return tagList, err
 		}
-		GlobalOption.Offset = uint32(offset)
-		tag, _ := c.parseTag(packet, programName)
 		tagList = append(tagList, tag)
-		if programName == "" {
-			if strings.Contains(tag.TagName, "Program:") {
-				ProgramNames[tag.TagName] = tag.TagName
+		entries = entries[tagListEntrySize+tagLen:]
+	}
+	for i, t := range tagList {
+		c.knownTags[t.TagName] = t.DataType
+		if t.IsStructure() {
+			c.knownTags[t.TagName] = 160
+			if tmpl, ok := c.knownTemplates[t.TemplateID()]; ok {
+				tagList[i].TemplateName = tmpl.Name
 			}
 		}
-		packetStart += tagLen + 10
-	}
-	for _, t := range tagList {
-		knownTags[t.TagName] = t.DataType
+		c.knownSymbolTypes[t.TagName] = t.SymbolType
+		c.knownInstances[t.TagName] = t.InstanceID
 	}
 	return tagList, nil
 }
 
-func (c *client) ParseOutput(tag string, data []byte) (interface{}, error) {
-	var dataType uint8
-	binary.Read(bytes.NewBuffer(data[:1]), binary.LittleEndian, &dataType)
-
-	tagSplit := strings.Split(tag, ".")
+func (c *client) ParseOutput(path *TagPath, data []byte) (interface{}, error) {
+	if len(data) < 2 {
+		return nil, errors.New("eip: read reply carries no data type")
+	}
+	dataType := data[0]
+	if size := valueSize(dataType); len(data) < 2+size {
+		return nil, fmt.Errorf("eip: read reply holds %d bytes, a value of type %d needs %d", len(data)-2, dataType, size)
+	}
 
 	switch dataType {
 	case 193:
 		var v bool
 		binary.Read(bytes.NewBuffer(data[2:2+1]), binary.LittleEndian, &v)
 		return v, nil
-	case 194, 195, 196, 197, 198, 199, 200, 201, 211:
-		byteCount := c.getByteCount(dataType).ByteCount
-		getBool := false
-		pos, err := strconv.Atoi(tagSplit[len(tagSplit)-1])
-		if err == nil {
-			getBool = true
+	case 211:
+		var v uint32
+		binary.Read(bytes.NewBuffer(data[2:6]), binary.LittleEndian, &v)
+		bit := 0
+		if len(path.Last().Indices) > 0 {
+			bit = path.Last().Indices[0] % 32
 		}
+		return (v>>uint(bit))&1 == 1, nil
+	case 194, 195, 196, 197, 198, 199, 200, 201:
+		byteCount := c.getByteCount(dataType).ByteCount
+		getBool := path.HasBit()
+		pos := path.Bit
 
 		switch byteCount {
 		case 1:
@@ -1020,80 +1103,258 @@ func (c *client) ParseOutput(tag string, data []byte) (interface{}, error) {
 		}
 	case 202:
 		var v float32
-		binary.Read(bytes.NewBuffer(data[2:2+4*8]), binary.LittleEndian, &v)
+		binary.Read(bytes.NewBuffer(data[2:6]), binary.LittleEndian, &v)
 		return v, nil
 	case 203:
 		var v float64
-		binary.Read(bytes.NewBuffer(data[2:2+8*8]), binary.LittleEndian, &v)
+		binary.Read(bytes.NewBuffer(data[2:10]), binary.LittleEndian, &v)
 		return v, nil
 	case 160:
 		var strLen uint32
 		binary.Read(bytes.NewBuffer(data[4:8]), binary.LittleEndian, &strLen)
-		return string(data[8 : 8+uint8(strLen)]), nil
+		if int(strLen) > len(data)-8 {
+			return nil, errors.New("eip: string length exceeds the reply")
+		}
+		return string(data[8 : 8+strLen]), nil
 	default:
 		return nil, errors.New("unknown dataType")
 	}
 	return nil, errors.New("unknown error")
 }
 
-func (c *client) getDataType(tag string) (uint8, error) {
-	var dataType uint8
-	if _, ok := knownTags[tag]; !ok {
-		r := c.BuildPartialReadRequest(tag)
-		response, err := c.send(NewProtocolDataUnit(r))
-		if err != nil {
-			return dataType, err
-		}
-		if s := c.getStatus(response.Data); s != 0 {
-			return dataType, errors.New(ErrorText(int(s)))
-		}
-		if e := binary.Read(bytes.NewBuffer(response.Data[50:51]), binary.LittleEndian, &dataType); e != nil {
-			return 0, e
-		}
-		knownTags[tag] = dataType
+// valueSize is the number of value bytes ParseOutput reads after the type;
+// a string is measured again once its length is known.
+func valueSize(dataType uint8) int {
+	if dataType == 160 {
+		return 6
 	}
-	return dataType, nil
+	return int(cipTypeMap[dataType].ByteCount)
 }
 
-func (c *client) _getTagList(p string) ([]Tag, error) {
-	tagList := make([]Tag, 0)
+func (c *client) getDataType(path *TagPath) (uint8, error) {
+	c.checkProjectChange()
+	tag := path.String()
+	if t, ok := c.knownTags[tag]; ok {
+		return t, nil
+	}
 
-	tagListRequest := c.BuildTagListRequest(p)
-	response, err := c.send(NewProtocolDataUnit(tagListRequest))
+	if GlobalOption.InstanceAddressing && len(path.Segments) > 1 {
+		// reading the templates lets buildTagIOI address members by ID
+		c.resolveSymbolType(path)
+	}
+	dataType, err := c.partialReadDataType(path, false)
+	if err != nil && len(path.Last().Indices) == 1 && !path.HasBit() {
+		// BOOL arrays are stored as DWORDs, so bit 37 only exists as word 1
+		if t, e := c.partialReadDataType(path, true); e == nil && t == 211 {
+			dataType, err = t, nil
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	c.knownTags[tag] = dataType
+	return dataType, nil
+}
+func (c *client) partialReadDataType(path *TagPath, isBoolArray bool) (uint8, error) {
+	var dataType uint8
+	r := c.BuildPartialReadRequest(path, isBoolArray)
+	response, err := c.send(NewProtocolDataUnit(r))
+	if err != nil {
+		return dataType, err
+	}
+	data, status, err := cipReplyData(response.Data)
 	if err != nil {
-		return tagList, err
+		return dataType, err
+	}
+	if !status.OK() {
+		return dataType, errors.New(ErrorText(int(status.General)))
+	}
+	if len(data) < 1 {
+		return dataType, errors.New("eip: read reply carries no data type")
 	}
-	tList, e := c.ExtractTagPacket(response.Data, p)
+	return data[0], nil
+}
+func (c *client) parseBoolArray(tag string) (*TagPath, error) {
+	path, e := ParseTagPath(tag)
 	if e != nil {
-		return tagList, e
+		return nil, e
+	}
+	if path.HasBit() || len(path.Last().Indices) > 1 {
+		return nil, errors.New("eip: " + tag + " does not address a BOOL array")
+	}
+	if len(path.Last().Indices) == 0 {
+		path = path.Element(0)
 	}
-	tagList = append(tagList, tList...)
+	return path, nil
+}
 
-	status := c.getStatus(response.Data)
-	for status == 6 {
-		tagListRequest := c.BuildTagListRequest(p)
+func (c *client) _getTagList(p string) ([]Tag, error) {
+	c.checkProjectChange()
+	tagList := make([]Tag, 0)
+	seen := make(map[uint32]bool)
+
+	var instance uint32
+	for {
+		tagListRequest := c.BuildTagListRequest(p, instance)
 		response, err := c.send(NewProtocolDataUnit(tagListRequest))
 		if err != nil {
 			return tagList, err
 		}
-		status = c.getStatus(response.Data)
+		status := c.getStatus(response.Data)
+		if status != 0 && status != 6 {
+			return tagList, errors.New(ErrorText(int(status)))
+		}
 		tList, e := c.ExtractTagPacket(response.Data, p)
 		if e != nil {
 			return tagList, e
 		}
-		tagList = append(tagList, tList...)
-		time.Sleep(250 * time.Millisecond)
+
+		next := instance
+		for _, t := range tList {
+			if !seen[t.InstanceID] {
+				seen[t.InstanceID] = true
+				tagList = append(tagList, t)
+			}
+			if t.InstanceID >= next {
+				next = t.InstanceID + 1
+			}
+		}
+		if status == 0 {
+			return tagList, nil
+		}
+		// the next page starts after the last instance this one returned
+		if next == instance {
+			return tagList, fmt.Errorf("eip: tag list for %q stopped advancing at instance %d", p, instance)
+		}
+		instance = next
 	}
+}
 
-	return tagList, nil
+func (c *client) sendCIP(service uint8, path []byte, data []byte) ([]byte, uint8, error) {
+	reply, status, err := c.sendCIPMessage(service, path, data)
+	if err != nil {
+		return nil, status.General, err
+	}
+	if !status.OK() {
+		return nil, status.General, errors.New(ErrorText(int(status.General)))
+	}
+	return reply, status.General, nil
+}
+func (c *client) sendCIPMessage(service uint8, path []byte, data []byte) ([]byte, MessageStatus, error) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{service, uint8(len(path) / 2)})
+	buf.Write(path)
+	buf.Write(data)
+
+	response, err := c.send(NewProtocolDataUnit(c.BuildEIPHeader(buf.Bytes())))
+	if err != nil {
+		return nil, MessageStatus{}, err
+	}
+	return cipReplyData(response.Data)
 }
 
+// getAttributeList reads attributes of an instance with Get_Attribute_List
+// and returns each value keyed by attribute ID; sizes gives the byte size of
+// each attribute.
+func (c *client) getAttributeList(class uint16, instance uint32, attributes []uint16, sizes []int) (map[uint16][]byte, error) {
+	request := new(bytes.Buffer)
+	binary.Write(request, binary.LittleEndian, uint16(len(attributes)))
+	binary.Write(request, binary.LittleEndian, attributes)
+
+	data, _, err := c.GenericMessage(0x03, class, instance, 0, request.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, errors.New("eip: attribute list reply is truncated")
+	}
+	values := make(map[uint16][]byte, len(attributes))
+	data = data[2:]
+	for i := range attributes {
+		if len(data) < 4 {
+			return nil, errors.New("eip: attribute list reply is truncated")
+		}
+		id := binary.LittleEndian.Uint16(data[0:2])
+		if id != attributes[i] {
+			return nil, fmt.Errorf("eip: attribute list reply has attribute %d, expected %d", id, attributes[i])
+		}
+		if status := binary.LittleEndian.Uint16(data[2:4]); status != 0 {
+			return nil, fmt.Errorf("eip: attribute %d: %s", id, ErrorText(int(status)))
+		}
+		if len(data) < 4+sizes[i] {
+			return nil, errors.New("eip: attribute list reply is truncated")
+		}
+		values[id] = data[4 : 4+sizes[i]]
+		data = data[4+sizes[i]:]
+	}
+	return values, nil
+}
+func (c *client) setAttributeList(class uint16, instance uint32, attributes []uint16, values [][]byte) error {
+	request := new(bytes.Buffer)
+	binary.Write(request, binary.LittleEndian, uint16(len(attributes)))
+	for i, id := range attributes {
+		binary.Write(request, binary.LittleEndian, id)
+		request.Write(values[i])
+	}
+
+	data, _, err := c.GenericMessage(0x04, class, instance, 0, request.Bytes())
+	if err != nil {
+		return err
+	}
+	if len(data) < 2 {
+		return errors.New("eip: attribute list reply is truncated")
+	}
+	data = data[2:]
+	for range attributes {
+		if len(data) < 4 {
+			return errors.New("eip: attribute list reply is truncated")
+		}
+		if status := binary.LittleEndian.Uint16(data[2:4]); status != 0 {
+			return fmt.Errorf("eip: attribute %d: %s", binary.LittleEndian.Uint16(data[0:2]), ErrorText(int(status)))
+		}
+		data = data[4:]
+	}
+	return nil
+}
+func (c *client) sendUnconnected(service uint8, path []byte, data []byte, route []byte) ([]byte, uint8, error) {
+	reply, status, err := c.sendUnconnectedMessage(service, path, data, route)
+	if err != nil {
+		return nil, status.General, err
+	}
+	if !status.OK() {
+		return nil, status.General, errors.New(ErrorText(int(status.General)))
+	}
+	return reply, status.General, nil
+}
+func (c *client) sendUnconnectedMessage(service uint8, path []byte, data []byte, route []byte) ([]byte, MessageStatus, error) {
+	request := c.buildUnconnectedRequest(service, path, data)
+	if len(route) > 0 {
+		request = c.BuildUnconnectedSendRequest(service, path, data, route)
+	}
+	response, err := c.send(NewProtocolDataUnit(request))
+	if err != nil {
+		return nil, MessageStatus{}, err
+	}
+	return cipReplyData(response.Data)
+}
 func (c *client) send(request *ProtocolDataUnit) (response *ProtocolDataUnit, err error) {
+	c.mu.Lock()
+	c.lastRequest = time.Now()
+	if len(request.Data) >= 2 && binary.LittleEndian.Uint16(request.Data[0:2]) == encapSendUnitData {
+		c.lastConnected = c.lastRequest
+	}
 	dataResponse, err := c.transporter.Send(request.Data)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 
 	if err = c.packager.Verify(request.Data, dataResponse); err != nil {
 		return
 	}
+	if err = verifyConnection(dataResponse, c.toConnectionID); err != nil {
+		return
+	}
 	if dataResponse == nil || len(dataResponse) == 0 {
 		err = fmt.Errorf("eip: response data is empty")
 		return
@@ -1104,5 +1365,12 @@ func (c *client) send(request *ProtocolDataUnit) (response *ProtocolDataUnit, er
 }
 
 func responseError(response *ProtocolDataUnit) error {
+	h, _, err := ParseEncapsulation(response.Data)
+	if err != nil {
+		return err
+	}
+	if h.Status != 0 {
+		return fmt.Errorf("eip: encapsulation status %#x: %s", h.Status, encapStatusText(h.Status))
+	}
 	return nil
 }
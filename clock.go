@@ -0,0 +1,108 @@
+package go_eip
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Wall Clock Time object and its attributes.
+const (
+	wallClockClass = 0x8B
+
+	// both values count microseconds since 1970; the current value in UTC,
+	// the local value on the controller's wall clock
+	wallClockCurrentValue  = 0x06
+	wallClockTimeZone      = 0x07
+	wallClockDSTAdjustment = 0x09
+	wallClockApplyDST      = 0x0A
+	wallClockLocalValue    = 0x0B
+)
+
+func (c *client) GetPLCTime() (time.Time, error) {
+	values, err := c.getAttributeList(wallClockClass, 1, []uint16{wallClockCurrentValue}, []int{8})
+	if err != nil {
+		return time.Time{}, err
+	}
+	us := binary.LittleEndian.Uint64(values[wallClockCurrentValue])
+	return time.Unix(0, 0).UTC().Add(time.Microsecond * time.Duration(us)), nil
+}
+func (c *client) SetPLCTime(t time.Time) error {
+	us := make([]byte, 8)
+	binary.LittleEndian.PutUint64(us, uint64(t.UnixNano()/1e3))
+	return c.setAttributeList(wallClockClass, 1, []uint16{wallClockCurrentValue}, [][]byte{us})
+}
+
+type PLCTimeZone struct {
+	Offset        time.Duration
+	DSTAdjustment time.Duration
+	ApplyDST      bool
+}
+
+// Location is a fixed zone for the controller's current local offset,
+// including the DST adjustment when the controller applies it.
+func (z PLCTimeZone) Location() *time.Location {
+	offset := z.Offset
+	if z.ApplyDST {
+		offset += z.DSTAdjustment
+	}
+	return time.FixedZone("PLC", int(offset/time.Second))
+}
+
+func (c *client) GetPLCTimeZone() (PLCTimeZone, error) {
+	values, err := c.getAttributeList(wallClockClass, 1,
+		[]uint16{wallClockTimeZone, wallClockDSTAdjustment, wallClockApplyDST},
+		[]int{2, 2, 1})
+	if err != nil {
+		return PLCTimeZone{}, err
+	}
+	return PLCTimeZone{
+		Offset:        time.Duration(int16(binary.LittleEndian.Uint16(values[wallClockTimeZone]))) * time.Minute,
+		DSTAdjustment: time.Duration(int16(binary.LittleEndian.Uint16(values[wallClockDSTAdjustment]))) * time.Minute,
+		ApplyDST:      values[wallClockApplyDST][0] != 0,
+	}, nil
+}
+
+func (c *client) SetPLCTimeZone(z PLCTimeZone) error {
+	offset := make([]byte, 2)
+	binary.LittleEndian.PutUint16(offset, uint16(int16(z.Offset/time.Minute)))
+	dst := make([]byte, 2)
+	binary.LittleEndian.PutUint16(dst, uint16(int16(z.DSTAdjustment/time.Minute)))
+	applyDST := []byte{0}
+	if z.ApplyDST {
+		applyDST[0] = 1
+	}
+
+	return c.setAttributeList(wallClockClass, 1,
+		[]uint16{wallClockTimeZone, wallClockDSTAdjustment, wallClockApplyDST},
+		[][]byte{offset, dst, applyDST})
+}
+
+// GetPLCLocalTime reads the controller's local wall clock and returns it in
+// the controller's configured time zone.
+func (c *client) GetPLCLocalTime() (time.Time, error) {
+	z, err := c.GetPLCTimeZone()
+	if err != nil {
+		return time.Time{}, err
+	}
+	values, err := c.getAttributeList(wallClockClass, 1, []uint16{wallClockLocalValue}, []int{8})
+	if err != nil {
+		return time.Time{}, err
+	}
+	us := binary.LittleEndian.Uint64(values[wallClockLocalValue])
+	wall := time.Unix(0, 0).UTC().Add(time.Microsecond * time.Duration(us))
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), z.Location()), nil
+}
+
+// SetPLCLocalTime sets the controller's local wall clock to t as seen in the
+// controller's configured time zone.
+func (c *client) SetPLCLocalTime(t time.Time) error {
+	z, err := c.GetPLCTimeZone()
+	if err != nil {
+		return err
+	}
+	local := t.In(z.Location())
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	us := make([]byte, 8)
+	binary.LittleEndian.PutUint64(us, uint64(wall.UnixNano()/1e3))
+	return c.setAttributeList(wallClockClass, 1, []uint16{wallClockLocalValue}, [][]byte{us})
+}
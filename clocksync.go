@@ -0,0 +1,147 @@
+package go_eip
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// MaintenanceWindow is a daily span of local time, given as offsets from
+// midnight, during which ClockSync may step a controller's clock. End may be
+// earlier than Start for a window that spans midnight.
+type MaintenanceWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	since := t.Sub(midnight)
+	if w.Start <= w.End {
+		return since >= w.Start && since < w.End
+	}
+	return since >= w.Start || since < w.End
+}
+
+// DriftSample is one clock comparison. Drift is positive when the controller
+// is ahead of the local clock.
+type DriftSample struct {
+	Name      string
+	Time      time.Time
+	Drift     time.Duration
+	RoundTrip time.Duration
+	Corrected bool
+	Err       error
+}
+
+type ClockSyncOptions struct {
+	// Name identifies the controller in logs and samples.
+	Name     string
+	Interval time.Duration
+	// Threshold is the absolute drift above which the clock is corrected;
+	// zero only measures.
+	Threshold time.Duration
+	// Window restricts corrections; nil allows them at any time.
+	Window  *MaintenanceWindow
+	OnDrift func(DriftSample)
+	Logger  *log.Logger
+	// Now is the local reference clock, time.Now when nil.
+	Now func() time.Time
+}
+
+type ClockSync struct {
+	client  Client
+	options ClockSyncOptions
+}
+
+func NewClockSync(client Client, options ClockSyncOptions) *ClockSync {
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return &ClockSync{client: client, options: options}
+}
+
+// Measure compares the controller clock with the local clock, taking the
+// local time halfway through the request to cancel out the round trip.
+func (s *ClockSync) Measure() (DriftSample, error) {
+	sample := DriftSample{Name: s.options.Name}
+	before := s.options.Now()
+	plc, err := s.client.GetPLCTime()
+	after := s.options.Now()
+	if err != nil {
+		sample.Time = after
+		sample.Err = err
+		return sample, err
+	}
+	sample.RoundTrip = after.Sub(before)
+	sample.Time = before.Add(sample.RoundTrip / 2)
+	sample.Drift = plc.Sub(sample.Time)
+	return sample, nil
+}
+
+// Sync measures the drift once and corrects the clock when the drift exceeds
+// the threshold inside the maintenance window. The sample is reported to
+// OnDrift and the logger whether or not it succeeded.
+func (s *ClockSync) Sync() (DriftSample, error) {
+	sample, err := s.Measure()
+	if err == nil && s.needsCorrection(sample) {
+		// the write takes about half a round trip to reach the controller
+		now := s.options.Now()
+		if err = s.client.SetPLCTime(now.Add(sample.RoundTrip / 2)); err != nil {
+			sample.Err = err
+		} else {
+			sample.Corrected = true
+		}
+	}
+	s.report(sample)
+	return sample, err
+}
+
+// Run calls Sync every Interval until ctx is done. Failed samples are
+// reported and do not stop the loop.
+func (s *ClockSync) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.options.Interval)
+	defer ticker.Stop()
+	for {
+		s.Sync()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ClockSync) needsCorrection(sample DriftSample) bool {
+	if s.options.Threshold <= 0 {
+		return false
+	}
+	drift := sample.Drift
+	if drift < 0 {
+		drift = -drift
+	}
+	return drift > s.options.Threshold && s.options.Window.Contains(sample.Time)
+}
+
+func (s *ClockSync) report(sample DriftSample) {
+	if s.options.OnDrift != nil {
+		s.options.OnDrift(sample)
+	}
+	if s.options.Logger == nil {
+		return
+	}
+	switch {
+	case sample.Err != nil:
+		s.options.Logger.Printf("%s: clock sync: %v", sample.Name, sample.Err)
+	case sample.Corrected:
+		s.options.Logger.Printf("%s: drift %v (rtt %v), clock corrected", sample.Name, sample.Drift, sample.RoundTrip)
+	default:
+		s.options.Logger.Printf("%s: drift %v (rtt %v)", sample.Name, sample.Drift, sample.RoundTrip)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go_eip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const usage = `usage:
+  eiptool export -addr <ip> [-slot n] [-format json|csv] [-o file]
+  eiptool diff <old export> <new export>
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = export(os.Args[2:])
+	case "diff":
+		var changed bool
+		changed, err = diff(os.Args[2:])
+		if err == nil && changed {
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	addr := fs.String("addr", "", "controller address")
+	slot := fs.Int("slot", 0, "processor slot")
+	format := fs.String("format", "", "json or csv, taken from -o when omitted")
+	output := fs.String("o", "", "output file, stdout when omitted")
+	fs.Parse(args)
+	if *addr == "" {
+		return fmt.Errorf("export: -addr is required")
+	}
+	if *format == "" {
+		*format = formatOf(*output)
+	}
+
+	handler := go_eip.NewTCPClientHandler(*addr)
+	if err := handler.Connect(); err != nil {
+		return err
+	}
+	client := go_eip.NewClient(handler, *slot)
+	if client == nil {
+		return fmt.Errorf("export: could not open a session with %s", *addr)
+	}
+	defer client.Stop()
+
+	tags, err := client.GetTagList()
+	if err != nil {
+		return err
+	}
+	for i, t := range tags {
+		if !t.IsStructure() {
+			continue
+		}
+		if tmpl, err := client.GetTemplate(t.TemplateID()); err == nil {
+			tags[i].TemplateName = tmpl.Name
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return go_eip.ExportTags(w, tags, *format)
+}
+
+func diff(args []string) (bool, error) {
+	if len(args) != 2 {
+		return false, fmt.Errorf("diff: expected two export files")
+	}
+	a, err := load(args[0])
+	if err != nil {
+		return false, err
+	}
+	b, err := load(args[1])
+	if err != nil {
+		return false, err
+	}
+
+	d := go_eip.DiffTags(a, b)
+	for _, r := range d.Added {
+		fmt.Printf("+ %s %s\n", r.Name, r.TypeString())
+	}
+	for _, r := range d.Removed {
+		fmt.Printf("- %s %s\n", r.Name, r.TypeString())
+	}
+	for _, c := range d.Retyped {
+		from, to := c.From.TypeString(), c.To.TypeString()
+		if from == to {
+			// e.g. one structure for another, tell them apart by symbol type
+			from = fmt.Sprintf("%s (%#04x)", from, c.From.SymbolType)
+			to = fmt.Sprintf("%s (%#04x)", to, c.To.SymbolType)
+		}
+		fmt.Printf("~ %s %s -> %s\n", c.Name, from, to)
+	}
+	return !d.Empty(), nil
+}
+
+func load(filename string) ([]go_eip.TagRecord, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return go_eip.ImportTags(f, formatOf(filename))
+}
+
+func formatOf(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return "csv"
+	}
+	return "json"
+}
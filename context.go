@@ -0,0 +1,46 @@
+package go_eip
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// ContextGenerator returns the sender context for the next request. The
+// device echoes it in its reply, so it also identifies the request in a
+// packet capture.
+type ContextGenerator func() uint64
+
+// NewContextGenerator numbers requests in the low 32 bits under a random
+// prefix, so two clients talking to one device never share a context.
+func NewContextGenerator() ContextGenerator {
+	prefix := uint64(rand.Uint32()) << 32
+	var counter uint32
+	return func() uint64 {
+		return prefix | uint64(atomic.AddUint32(&counter, 1))
+	}
+}
+
+// SetContextGenerator replaces the client's sender contexts with those of g;
+// nil restores a fresh default generator.
+func (c *client) SetContextGenerator(g ContextGenerator) {
+	if g == nil {
+		g = NewContextGenerator()
+	}
+	c.contextMu.Lock()
+	c.contextGenerator = g
+	c.contextMu.Unlock()
+}
+
+// LastContext returns the sender context of the most recent request.
+func (c *client) LastContext() uint64 {
+	c.contextMu.Lock()
+	defer c.contextMu.Unlock()
+	return c.lastContext
+}
+
+func (c *client) nextContext() uint64 {
+	c.contextMu.Lock()
+	defer c.contextMu.Unlock()
+	c.lastContext = c.contextGenerator()
+	return c.lastContext
+}
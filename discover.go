@@ -0,0 +1,149 @@
+package go_eip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// eipUDPPort is the EtherNet/IP port the list commands are sent to over
+	// UDP; it shares its number with the TCP port.
+	eipUDPPort = 44818
+
+	discoverBroadcast = "255.255.255.255"
+	discoverWindow    = 2 * time.Second
+
+	cpfListIdentity = 0x0C
+)
+
+// Discover broadcasts an EtherNet/IP ListIdentity request to address (a
+// broadcast or unicast IP, port 44818 unless given) and collects the replies
+// that arrive within window.
+func Discover(address string, window time.Duration) ([]Identity, error) {
+	if !strings.Contains(address, ":") {
+		address = address + ":" + strconv.Itoa(eipUDPPort)
+	}
+	target, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(buildListRequest(encapListIdentity, 0), target); err != nil {
+		return nil, err
+	}
+
+	identities := make([]Identity, 0)
+	seen := make(map[string]bool)
+	deadline := time.Now().Add(window)
+	buf := make([]byte, 1500)
+	for {
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return identities, err
+		}
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return identities, nil
+			}
+			return identities, err
+		}
+		found, err := parseListIdentity(buf[:n], from)
+		if err != nil {
+			continue
+		}
+		for _, id := range found {
+			key := id.IP.String() + "/" + strconv.FormatUint(uint64(id.SerialNumber), 10)
+			if !seen[key] {
+				seen[key] = true
+				identities = append(identities, id)
+			}
+		}
+	}
+}
+
+// Discover broadcasts ListIdentity to address, or to 255.255.255.255 when
+// address is empty. The limited broadcast only leaves through the interface
+// of the default route, so on a host with several networks pass the directed
+// broadcast of the one the devices are on, see BroadcastAddress.
+func (c *client) Discover(address string, window time.Duration) ([]Identity, error) {
+	if address == "" {
+		address = discoverBroadcast
+	}
+	return Discover(address, window)
+}
+
+// BroadcastAddress is the directed broadcast address of the first IPv4
+// network of the named interface, for use with Discover.
+func BroadcastAddress(name string) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		network, ok := addr.(*net.IPNet)
+		if !ok || network.IP.To4() == nil {
+			continue
+		}
+		ip := network.IP.To4()
+		mask := network.Mask
+		if len(mask) == net.IPv6len {
+			mask = mask[12:]
+		}
+		broadcast := make(net.IP, net.IPv4len)
+		for i := range broadcast {
+			broadcast[i] = ip[i] | ^mask[i]
+		}
+		return broadcast.String(), nil
+	}
+	return "", fmt.Errorf("eip: interface %s has no IPv4 address", name)
+}
+
+func parseListIdentity(reply []byte, from *net.UDPAddr) ([]Identity, error) {
+	items, err := splitListItems(reply, encapListIdentity)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]Identity, 0, len(items))
+	for _, item := range items {
+		if item.TypeID != cpfListIdentity {
+			continue
+		}
+
+		// encapsulation version, then a big-endian sockaddr_in
+		if len(item.Data) < 2 {
+			return identities, errors.New("eip: ListIdentity item is truncated")
+		}
+		addr, err := ParseSockaddr(item.Data[2:])
+		if err != nil {
+			return identities, err
+		}
+		body := item.Data[2+sockaddrSize:]
+		id, n, err := parseIdentity(body)
+		if err != nil {
+			return identities, err
+		}
+		if len(body) > n {
+			id.State = body[n]
+		}
+		id.IP = addr.IP
+		if id.IP.IsUnspecified() && from != nil {
+			id.IP = from.IP
+		}
+		identities = append(identities, id)
+	}
+	return identities, nil
+}
@@ -0,0 +1,356 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+const encapHeaderSize = 24
+
+const (
+	encapRegisterSession   = 0x65
+	encapUnregisterSession = 0x66
+	encapSendRRData        = 0x6F
+	encapSendUnitData      = 0x70
+
+	cipReplyBit        = 0x80
+	unconnectedSendErr = 0xD2
+)
+
+// Common Packet Format item type IDs.
+const (
+	cpfConnectedAddress = 0x00A1
+	cpfConnectedData    = 0x00B1
+	cpfUnconnectedData  = 0x00B2
+	cpfSockaddrOT       = 0x8000
+	cpfSockaddrTO       = 0x8001
+	cpfSequencedAddress = 0x8002
+)
+
+type EncapsulationHeader struct {
+	Command       uint16
+	Length        uint16
+	SessionHandle uint32
+	Status        uint32
+	Context       uint64
+	Options       uint32
+}
+
+type CPFItem struct {
+	TypeID uint16
+	Data   []byte
+}
+
+// Sockaddr is the sockaddr_in, in network byte order, carried in sockaddr
+// info items and ListIdentity replies.
+type Sockaddr struct {
+	Family uint16
+	Port   uint16
+	IP     net.IP
+}
+
+const sockaddrSize = 16
+
+// ParseSockaddr decodes the 16-byte sockaddr_in at the start of data.
+func ParseSockaddr(data []byte) (Sockaddr, error) {
+	if len(data) < sockaddrSize {
+		return Sockaddr{}, errors.New("eip: sockaddr is truncated")
+	}
+	return Sockaddr{
+		Family: binary.BigEndian.Uint16(data[0:2]),
+		Port:   binary.BigEndian.Uint16(data[2:4]),
+		IP:     net.IP(append([]byte{}, data[4:8]...)),
+	}, nil
+}
+
+// ConnectionID decodes a connected address or sequenced address item.
+func (item CPFItem) ConnectionID() (uint32, error) {
+	if item.TypeID != cpfConnectedAddress && item.TypeID != cpfSequencedAddress {
+		return 0, fmt.Errorf("eip: item %#x carries no connection ID", item.TypeID)
+	}
+	if len(item.Data) < 4 {
+		return 0, errors.New("eip: address item is truncated")
+	}
+	return binary.LittleEndian.Uint32(item.Data[0:4]), nil
+}
+
+// SequenceNumber decodes the sequence number of a sequenced address item.
+func (item CPFItem) SequenceNumber() (uint32, error) {
+	if item.TypeID != cpfSequencedAddress {
+		return 0, fmt.Errorf("eip: item %#x carries no sequence number", item.TypeID)
+	}
+	if len(item.Data) < 8 {
+		return 0, errors.New("eip: sequenced address item is truncated")
+	}
+	return binary.LittleEndian.Uint32(item.Data[4:8]), nil
+}
+
+// Sockaddr decodes an O->T or T->O sockaddr info item.
+func (item CPFItem) Sockaddr() (Sockaddr, error) {
+	if item.TypeID != cpfSockaddrOT && item.TypeID != cpfSockaddrTO {
+		return Sockaddr{}, fmt.Errorf("eip: item %#x carries no sockaddr", item.TypeID)
+	}
+	return ParseSockaddr(item.Data)
+}
+
+// ParseEncapsulation splits a frame into its header and the Length bytes of
+// command data that follow it.
+func ParseEncapsulation(frame []byte) (EncapsulationHeader, []byte, error) {
+	var h EncapsulationHeader
+	if len(frame) < encapHeaderSize {
+		return h, nil, errors.New("eip: reply is shorter than an encapsulation header")
+	}
+	binary.Read(bytes.NewReader(frame[:encapHeaderSize]), binary.LittleEndian, &h)
+	if len(frame) < encapHeaderSize+int(h.Length) {
+		return h, nil, fmt.Errorf("eip: encapsulation data is truncated, %d of %d bytes", len(frame)-encapHeaderSize, h.Length)
+	}
+	return h, frame[encapHeaderSize : encapHeaderSize+int(h.Length)], nil
+}
+
+// ParseCPF decodes a Common Packet Format item list: an item count followed
+// by type, length and data of each item.
+func ParseCPF(data []byte) ([]CPFItem, error) {
+	if len(data) < 2 {
+		return nil, errors.New("eip: item count is truncated")
+	}
+	count := int(binary.LittleEndian.Uint16(data[0:2]))
+	data = data[2:]
+	items := make([]CPFItem, 0, count)
+	for i := 0; i < count; i++ {
+		if len(data) < 4 {
+			return items, errors.New("eip: item header is truncated")
+		}
+		itemLen := int(binary.LittleEndian.Uint16(data[2:4]))
+		if len(data) < 4+itemLen {
+			return items, errors.New("eip: item data is truncated")
+		}
+		items = append(items, CPFItem{TypeID: binary.LittleEndian.Uint16(data[0:2]), Data: data[4 : 4+itemLen]})
+		data = data[4+itemLen:]
+	}
+	return items, nil
+}
+
+func findItem(items []CPFItem, typeID uint16) (CPFItem, bool) {
+	for _, item := range items {
+		if item.TypeID == typeID {
+			return item, true
+		}
+	}
+	return CPFItem{}, false
+}
+
+// parseSendData decodes a SendRRData or SendUnitData reply, whose item list
+// follows the interface handle and timeout.
+func parseSendData(frame []byte) (EncapsulationHeader, []CPFItem, error) {
+	h, data, err := ParseEncapsulation(frame)
+	if err != nil {
+		return h, nil, err
+	}
+	if h.Status != 0 {
+		return h, nil, fmt.Errorf("eip: encapsulation status %#x", h.Status)
+	}
+	if len(data) < 6 {
+		return h, nil, errors.New("eip: send data reply is truncated")
+	}
+	items, err := ParseCPF(data[6:])
+	return h, items, err
+}
+
+// cipMessage returns the CIP reply carried in a SendRRData or SendUnitData
+// frame, starting at its service byte.
+func cipMessage(frame []byte) ([]byte, error) {
+	_, items, err := parseSendData(frame)
+	if err != nil {
+		return nil, err
+	}
+	if item, ok := findItem(items, cpfConnectedData); ok {
+		// connected data starts with the sequence count
+		if len(item.Data) < 2 {
+			return nil, errors.New("eip: connected data item is truncated")
+		}
+		return item.Data[2:], nil
+	}
+	if item, ok := findItem(items, cpfUnconnectedData); ok {
+		return item.Data, nil
+	}
+	return nil, errors.New("eip: reply carries no data item")
+}
+
+// cipReplyData returns the data and status of the CIP reply in frame.
+func cipReplyData(frame []byte) ([]byte, MessageStatus, error) {
+	message, err := cipMessage(frame)
+	if err != nil {
+		return nil, MessageStatus{General: 0xFF}, err
+	}
+	return parseMessageReply(message)
+}
+
+func encapStatusText(status uint32) string {
+	switch status {
+	case 0x01:
+		return "invalid or unsupported command"
+	case 0x02:
+		return "insufficient memory"
+	case 0x03:
+		return "incorrect data"
+	case 0x64:
+		return "invalid session handle"
+	case 0x65:
+		return "invalid length"
+	case 0x69:
+		return "unsupported protocol revision"
+	}
+	return "unknown status"
+}
+
+// verifyReply checks that response answers request: same command, session
+// and sender context, and for SendRRData and SendUnitData the sequence count
+// and reply service the request calls for. The connection ID belongs to the
+// client and is checked by verifyConnection.
+func verifyReply(request []byte, response []byte) error {
+	req, reqData, err := ParseEncapsulation(request)
+	if err != nil {
+		return err
+	}
+	if len(response) == 0 {
+		// NOP and UnregisterSession are the only commands left unanswered
+		if req.Command == encapNOP || req.Command == encapUnregisterSession {
+			return nil
+		}
+		return fmt.Errorf("eip: no reply to command %#x", req.Command)
+	}
+	resp, _, err := ParseEncapsulation(response)
+	if err != nil {
+		return err
+	}
+	if resp.Command != req.Command {
+		return fmt.Errorf("eip: reply is for command %#x, expected %#x", resp.Command, req.Command)
+	}
+	if req.Command != encapRegisterSession && resp.SessionHandle != req.SessionHandle {
+		return fmt.Errorf("eip: reply is for session %#x, expected %#x", resp.SessionHandle, req.SessionHandle)
+	}
+	if resp.Context != req.Context {
+		return fmt.Errorf("eip: reply sender context %#x does not match request %#x", resp.Context, req.Context)
+	}
+	if resp.Status != 0 || (req.Command != encapSendRRData && req.Command != encapSendUnitData) {
+		return nil
+	}
+
+	if len(reqData) < 6 {
+		return errors.New("eip: request data is truncated")
+	}
+	reqItems, err := ParseCPF(reqData[6:])
+	if err != nil {
+		return err
+	}
+	_, respItems, err := parseSendData(response)
+	if err != nil {
+		return err
+	}
+
+	var reqMessage, respMessage []byte
+	if req.Command == encapSendUnitData {
+		reqMessage, respMessage, err = verifyConnectedItems(reqItems, respItems)
+		if err != nil {
+			return err
+		}
+	} else {
+		reqItem, ok := findItem(reqItems, cpfUnconnectedData)
+		if !ok {
+			return nil
+		}
+		respItem, ok := findItem(respItems, cpfUnconnectedData)
+		if !ok {
+			return errors.New("eip: reply carries no unconnected data item")
+		}
+		reqMessage, respMessage = reqItem.Data, respItem.Data
+	}
+	return verifyReplyService(reqMessage, respMessage)
+}
+
+func verifyConnectedItems(reqItems, respItems []CPFItem) ([]byte, []byte, error) {
+	respAddress, ok := findItem(respItems, cpfConnectedAddress)
+	if !ok {
+		return nil, nil, errors.New("eip: reply carries no connected address item")
+	}
+	if _, err := respAddress.ConnectionID(); err != nil {
+		return nil, nil, err
+	}
+
+	reqData, ok := findItem(reqItems, cpfConnectedData)
+	if !ok || len(reqData.Data) < 2 {
+		return nil, nil, errors.New("eip: request carries no connected data item")
+	}
+	respData, ok := findItem(respItems, cpfConnectedData)
+	if !ok || len(respData.Data) < 2 {
+		return nil, nil, errors.New("eip: reply carries no connected data item")
+	}
+	reqSeq := binary.LittleEndian.Uint16(reqData.Data[0:2])
+	respSeq := binary.LittleEndian.Uint16(respData.Data[0:2])
+	if reqSeq != respSeq {
+		return nil, nil, fmt.Errorf("eip: reply has sequence count %d, expected %d", respSeq, reqSeq)
+	}
+	return reqData.Data[2:], respData.Data[2:], nil
+}
+
+// verifyConnection checks a SendUnitData reply arrived on the T->O
+// connection the client opened. Other replies carry no connection.
+func verifyConnection(response []byte, connectionID uint32) error {
+	h, _, err := ParseEncapsulation(response)
+	if err != nil || h.Command != encapSendUnitData || h.Status != 0 {
+		return nil
+	}
+	_, items, err := parseSendData(response)
+	if err != nil {
+		return err
+	}
+	address, ok := findItem(items, cpfConnectedAddress)
+	if !ok {
+		return errors.New("eip: reply carries no connected address item")
+	}
+	id, err := address.ConnectionID()
+	if err != nil {
+		return err
+	}
+	if id != connectionID {
+		return fmt.Errorf("eip: reply is for connection %#x, expected %#x", id, connectionID)
+	}
+	return nil
+}
+
+// verifyReplyService checks the reply service is the request service with
+// the reply bit set. Unconnected Send replies carry the embedded service, or
+// 0xD2 when routing failed.
+func verifyReplyService(request, reply []byte) error {
+	if len(request) == 0 {
+		return nil
+	}
+	if len(reply) == 0 {
+		return errors.New("eip: reply carries no CIP service")
+	}
+	want := request[0] | cipReplyBit
+	if request[0] == 0x52 && reply[0] != unconnectedSendErr {
+		if embedded, ok := embeddedService(request); ok {
+			want = embedded | cipReplyBit
+		}
+	}
+	if reply[0] != want {
+		return fmt.Errorf("eip: reply service %#x does not answer request service %#x", reply[0], request[0])
+	}
+	return nil
+}
+
+func embeddedService(request []byte) (uint8, bool) {
+	// service, path size, path, priority, timeout ticks, request size
+	if len(request) < 2 {
+		return 0, false
+	}
+	start := 2 + 2*int(request[1]) + 4
+	if len(request) <= start {
+		return 0, false
+	}
+	return request[start], true
+}
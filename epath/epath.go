@@ -0,0 +1,274 @@
+// Package epath builds and decodes padded CIP EPATHs: logical, port, ANSI
+// extended symbolic and simple data segments.
+package epath
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Kind uint8
+
+const (
+	KindPort     Kind = 0x00
+	KindLogical  Kind = 0x20
+	KindData     Kind = 0x80
+	KindSymbolic Kind = 0x91
+)
+
+type LogicalType uint8
+
+const (
+	LogicalClass           LogicalType = 0x00
+	LogicalInstance        LogicalType = 0x04
+	LogicalMember          LogicalType = 0x08
+	LogicalConnectionPoint LogicalType = 0x0C
+	LogicalAttribute       LogicalType = 0x10
+)
+
+const (
+	logicalFormat8  = 0x00
+	logicalFormat16 = 0x01
+	logicalFormat32 = 0x02
+
+	portExtendedLink = 0x10
+	portExtendedID   = 0x0F
+)
+
+// MaxSymbolLength is the longest name a symbolic segment can carry.
+const MaxSymbolLength = 255
+
+// Segment is one decoded path segment. Value holds logical values, Port and
+// Link port segments, Symbol symbolic segments and Data data segments.
+type Segment struct {
+	Kind    Kind
+	Logical LogicalType
+	Value   uint32
+	Port    uint16
+	Link    []byte
+	Symbol  string
+	Data    []byte
+}
+
+func Class(id uint32) []byte           { return logical(LogicalClass, id) }
+func Instance(id uint32) []byte        { return logical(LogicalInstance, id) }
+func Member(id uint32) []byte          { return logical(LogicalMember, id) }
+func ConnectionPoint(id uint32) []byte { return logical(LogicalConnectionPoint, id) }
+func Attribute(id uint32) []byte       { return logical(LogicalAttribute, id) }
+
+// Port builds a port segment. Port numbers above 14 and link addresses longer
+// than one byte, such as an IP address string, use the extended forms.
+func Port(port uint16, link []byte) []byte {
+	b := []byte{0}
+	if len(link) > 1 {
+		b[0] |= portExtendedLink
+		b = append(b, uint8(len(link)))
+	}
+	if port < portExtendedID {
+		b[0] |= uint8(port)
+	} else {
+		b[0] |= portExtendedID
+		b = binary.LittleEndian.AppendUint16(b, port)
+	}
+	b = append(b, link...)
+	return pad(b)
+}
+
+// Symbol builds an ANSI extended symbolic segment. It panics if name is
+// longer than MaxSymbolLength, so names from outside must be checked first.
+func Symbol(name string) []byte {
+	if len(name) > MaxSymbolLength {
+		panic(fmt.Sprintf("epath: symbol %.16q... is %d characters long", name, len(name)))
+	}
+	b := append([]byte{byte(KindSymbolic), uint8(len(name))}, name...)
+	return pad(b)
+}
+
+// Data builds a simple data segment; odd data is padded to whole words.
+func Data(data []byte) []byte {
+	b := append([]byte{byte(KindData), uint8((len(data) + 1) / 2)}, data...)
+	return pad(b)
+}
+
+// Join concatenates segments into one path.
+func Join(segments ...[]byte) []byte {
+	var path []byte
+	for _, s := range segments {
+		path = append(path, s...)
+	}
+	return path
+}
+
+// Bytes encodes the segment back into path bytes.
+func (s Segment) Bytes() []byte {
+	switch s.Kind {
+	case KindLogical:
+		return logical(s.Logical, s.Value)
+	case KindPort:
+		return Port(s.Port, s.Link)
+	case KindSymbolic:
+		return Symbol(s.Symbol)
+	case KindData:
+		return Data(s.Data)
+	}
+	return nil
+}
+
+func (s Segment) String() string {
+	switch s.Kind {
+	case KindLogical:
+		return fmt.Sprintf("%s %d", logicalNames[s.Logical], s.Value)
+	case KindPort:
+		if len(s.Link) == 1 {
+			return fmt.Sprintf("port %d/%d", s.Port, s.Link[0])
+		}
+		return fmt.Sprintf("port %d/%q", s.Port, s.Link)
+	case KindSymbolic:
+		return fmt.Sprintf("symbol %q", s.Symbol)
+	case KindData:
+		return fmt.Sprintf("data % X", s.Data)
+	}
+	return "unknown"
+}
+
+var logicalNames = map[LogicalType]string{
+	LogicalClass:           "class",
+	LogicalInstance:        "instance",
+	LogicalMember:          "member",
+	LogicalConnectionPoint: "connection point",
+	LogicalAttribute:       "attribute",
+}
+
+// String formats a path for logs, e.g. "port 1/0, class 2, instance 1".
+func String(path []byte) string {
+	segments, err := Parse(path)
+	parts := make([]string, len(segments))
+	for i, s := range segments {
+		parts[i] = s.String()
+	}
+	if err != nil {
+		parts = append(parts, err.Error())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Parse decodes a padded EPATH. The segments decoded before an error are
+// returned with it.
+func Parse(path []byte) ([]Segment, error) {
+	var segments []Segment
+	for pos := 0; pos < len(path); {
+		s, n, err := parseSegment(path[pos:])
+		if err != nil {
+			return segments, fmt.Errorf("epath: offset %d: %v", pos, err)
+		}
+		segments = append(segments, s)
+		pos += n
+	}
+	return segments, nil
+}
+
+func parseSegment(b []byte) (Segment, int, error) {
+	switch {
+	case b[0]&0xE0 == byte(KindPort):
+		return parsePort(b)
+	case b[0]&0xE0 == byte(KindLogical):
+		return parseLogical(b)
+	case b[0] == byte(KindSymbolic):
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return Segment{}, 0, errTruncated
+		}
+		n := 2 + int(b[1])
+		return Segment{Kind: KindSymbolic, Symbol: string(b[2:n])}, padded(n, len(b)), nil
+	case b[0] == byte(KindData):
+		if len(b) < 2 || len(b) < 2+2*int(b[1]) {
+			return Segment{}, 0, errTruncated
+		}
+		n := 2 + 2*int(b[1])
+		return Segment{Kind: KindData, Data: b[2:n]}, n, nil
+	}
+	return Segment{}, 0, fmt.Errorf("unsupported segment type 0x%02X", b[0])
+}
+
+func parsePort(b []byte) (Segment, int, error) {
+	s := Segment{Kind: KindPort, Port: uint16(b[0] & 0x0F)}
+	n, linkSize := 1, 1
+	if b[0]&portExtendedLink != 0 {
+		if len(b) < 2 {
+			return s, 0, errTruncated
+		}
+		linkSize = int(b[1])
+		n++
+	}
+	if s.Port == portExtendedID {
+		if len(b) < n+2 {
+			return s, 0, errTruncated
+		}
+		s.Port = binary.LittleEndian.Uint16(b[n : n+2])
+		n += 2
+	}
+	if len(b) < n+linkSize {
+		return s, 0, errTruncated
+	}
+	s.Link = b[n : n+linkSize]
+	return s, padded(n+linkSize, len(b)), nil
+}
+
+func parseLogical(b []byte) (Segment, int, error) {
+	s := Segment{Kind: KindLogical, Logical: LogicalType(b[0] & 0x1C)}
+	// service ID, special and extended logical segments do not carry a
+	// plain value
+	if _, ok := logicalNames[s.Logical]; !ok {
+		return s, 0, fmt.Errorf("unsupported logical segment 0x%02X", b[0])
+	}
+	switch b[0] & 0x03 {
+	case logicalFormat8:
+		if len(b) < 2 {
+			return s, 0, errTruncated
+		}
+		s.Value = uint32(b[1])
+		return s, 2, nil
+	case logicalFormat16:
+		if len(b) < 4 {
+			return s, 0, errTruncated
+		}
+		s.Value = uint32(binary.LittleEndian.Uint16(b[2:4]))
+		return s, 4, nil
+	case logicalFormat32:
+		if len(b) < 6 {
+			return s, 0, errTruncated
+		}
+		s.Value = binary.LittleEndian.Uint32(b[2:6])
+		return s, 6, nil
+	}
+	return s, 0, fmt.Errorf("reserved logical format 0x%02X", b[0])
+}
+
+var errTruncated = errors.New("segment is truncated")
+
+func logical(t LogicalType, id uint32) []byte {
+	head := byte(KindLogical) | byte(t)
+	switch {
+	case id < 1<<8:
+		return []byte{head | logicalFormat8, uint8(id)}
+	case id < 1<<16:
+		return binary.LittleEndian.AppendUint16([]byte{head | logicalFormat16, 0}, uint16(id))
+	}
+	return binary.LittleEndian.AppendUint32([]byte{head | logicalFormat32, 0}, id)
+}
+
+func pad(b []byte) []byte {
+	if len(b)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// padded is n rounded up to a whole word, as far as the path goes.
+func padded(n, limit int) int {
+	if n%2 != 0 && n < limit {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,222 @@
+package go_eip
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{"name", "type", "symbol_type", "element_size", "dimensions", "external_access", "instance"}
+
+type TagRecord struct {
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	SymbolType     uint16   `json:"symbolType"`
+	ElementSize    uint16   `json:"elementSize"`
+	Dimensions     []uint32 `json:"dimensions,omitempty"`
+	ExternalAccess string   `json:"externalAccess"`
+	Instance       uint32   `json:"instance"`
+}
+
+type TagChange struct {
+	Name string
+	From TagRecord
+	To   TagRecord
+}
+
+type TagDiff struct {
+	Added   []TagRecord
+	Removed []TagRecord
+	Retyped []TagChange
+}
+
+func NewTagRecord(t Tag) TagRecord {
+	r := TagRecord{
+		Name:           t.TagName,
+		Type:           t.TypeName(),
+		SymbolType:     t.SymbolType,
+		ElementSize:    t.ElementSize,
+		ExternalAccess: t.ExternalAccessName(),
+		Instance:       t.InstanceID,
+	}
+	for i := 0; i < t.ArrayDims() && i < len(t.Dimensions); i++ {
+		r.Dimensions = append(r.Dimensions, t.Dimensions[i])
+	}
+	return r
+}
+
+// TypeString is the record's type with its array dimensions, e.g. DINT[10,4].
+func (r TagRecord) TypeString() string {
+	if len(r.Dimensions) == 0 {
+		return r.Type
+	}
+	return r.Type + "[" + joinDimensions(r.Dimensions, ",") + "]"
+}
+
+// TemplateID is the template instance of a structure record, zero otherwise.
+func (r TagRecord) TemplateID() uint16 {
+	return Tag{SymbolType: r.SymbolType}.TemplateID()
+}
+
+// retyped reports whether b has another type than a. Every structure shows
+// as STRUCT until its template is read, so the template instance, the whole
+// symbol type word and the dimensions are compared along with the name.
+func retyped(a, b TagRecord) bool {
+	if a.TypeString() != b.TypeString() || a.SymbolType != b.SymbolType || a.TemplateID() != b.TemplateID() {
+		return true
+	}
+	if len(a.Dimensions) != len(b.Dimensions) {
+		return true
+	}
+	for i := range a.Dimensions {
+		if a.Dimensions[i] != b.Dimensions[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func ExportTags(w io.Writer, tags []Tag, format string) error {
+	records := make([]TagRecord, len(tags))
+	for i, t := range tags {
+		records[i] = NewTagRecord(t)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(csvHeader)
+		for _, r := range records {
+			cw.Write([]string{
+				r.Name,
+				r.Type,
+				strconv.Itoa(int(r.SymbolType)),
+				strconv.Itoa(int(r.ElementSize)),
+				joinDimensions(r.Dimensions, "x"),
+				r.ExternalAccess,
+				strconv.FormatUint(uint64(r.Instance), 10),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("eip: unknown export format %q", format)
+	}
+}
+
+func ImportTags(r io.Reader, format string) ([]TagRecord, error) {
+	switch format {
+	case "json":
+		var records []TagRecord
+		err := json.NewDecoder(r).Decode(&records)
+		return records, err
+	case "csv":
+		rows, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+			return nil, fmt.Errorf("eip: tag export is missing its header row")
+		}
+		records := make([]TagRecord, 0, len(rows)-1)
+		for i, row := range rows[1:] {
+			record, err := parseTagRecord(row)
+			if err != nil {
+				return nil, fmt.Errorf("eip: tag export line %d: %v", i+2, err)
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("eip: unknown export format %q", format)
+	}
+}
+
+// DiffTags reports the tags only in b as added, only in a as removed, and
+// those whose type, symbol type or dimensions differ as retyped.
+func DiffTags(a, b []TagRecord) TagDiff {
+	before := make(map[string]TagRecord, len(a))
+	for _, r := range a {
+		before[r.Name] = r
+	}
+	after := make(map[string]TagRecord, len(b))
+	for _, r := range b {
+		after[r.Name] = r
+	}
+
+	var diff TagDiff
+	for _, r := range b {
+		old, ok := before[r.Name]
+		if !ok {
+			diff.Added = append(diff.Added, r)
+		} else if retyped(old, r) {
+			diff.Retyped = append(diff.Retyped, TagChange{Name: r.Name, From: old, To: r})
+		}
+	}
+	for _, r := range a {
+		if _, ok := after[r.Name]; !ok {
+			diff.Removed = append(diff.Removed, r)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.Retyped, func(i, j int) bool { return diff.Retyped[i].Name < diff.Retyped[j].Name })
+	return diff
+}
+
+func (d TagDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Retyped) == 0
+}
+
+func parseTagRecord(row []string) (TagRecord, error) {
+	if len(row) != len(csvHeader) {
+		return TagRecord{}, fmt.Errorf("expected %d columns, found %d", len(csvHeader), len(row))
+	}
+	symbolType, err := strconv.ParseUint(row[2], 10, 16)
+	if err != nil {
+		return TagRecord{}, err
+	}
+	elementSize, err := strconv.ParseUint(row[3], 10, 16)
+	if err != nil {
+		return TagRecord{}, err
+	}
+	instance, err := strconv.ParseUint(row[6], 10, 32)
+	if err != nil {
+		return TagRecord{}, err
+	}
+	r := TagRecord{
+		Name:           row[0],
+		Type:           row[1],
+		SymbolType:     uint16(symbolType),
+		ElementSize:    uint16(elementSize),
+		ExternalAccess: row[5],
+		Instance:       uint32(instance),
+	}
+	if row[4] != "" {
+		for _, d := range strings.Split(row[4], "x") {
+			dim, err := strconv.ParseUint(d, 10, 32)
+			if err != nil {
+				return TagRecord{}, err
+			}
+			r.Dimensions = append(r.Dimensions, uint32(dim))
+		}
+	}
+	return r, nil
+}
+
+func joinDimensions(dims []uint32, sep string) string {
+	s := make([]string, len(dims))
+	for i, d := range dims {
+		s[i] = strconv.FormatUint(uint64(d), 10)
+	}
+	return strings.Join(s, sep)
+}
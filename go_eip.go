@@ -7,18 +7,17 @@ type ProtocolDataUnit struct {
 type Packager interface {
 	//getByteCount(uint8) CIPType
 	//getStatus([]byte) uint8
-	//TagNameParser(tag string, offset int) (string, string, int)
 	//BuildEIPHeader(tagIOI []byte) []byte
 	//BuildTagListRequest(programName string) []byte
 	//BuildRegisterSessionRequest() []byte
 	//BuildUnregisterSessionRequest() []byte
 	//BuildForwardOpenRequest() []byte
 	//BuildForwardCloseRequest() []byte
-	//BuildPartialReadRequest(tag string) []byte
+	//BuildPartialReadRequest(path *TagPath, isBoolArray bool) []byte
 	//
-	//BuildReadIOIRequest(tag string, isBoolArray bool, elements int) []byte
-	//BuildWriteIOIRequest(tag string, value interface{}) []byte
-	//BuildMultiReadRequest(tags ...string)[]byte
+	//BuildReadIOIRequest(path *TagPath, isBoolArray bool, elements int) []byte
+	//BuildWriteIOIRequest(path *TagPath, value interface{}) ([]byte, error)
+	//BuildMultiReadRequest(tags ...*TagPath)[]byte
 	//ExtractTagPacket([]byte, string) ([]Tag, error)
 
 	Verify(request []byte, response []byte) (err error)
@@ -0,0 +1,193 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"go_eip/epath"
+)
+
+type Revision struct {
+	Major uint8
+	Minor uint8
+}
+
+type IdentityStatus uint16
+
+type Identity struct {
+	VendorID     uint16
+	DeviceType   uint16
+	ProductCode  uint16
+	Revision     Revision
+	Status       IdentityStatus
+	SerialNumber uint32
+	ProductName  string
+	State        uint8
+	IP           net.IP
+}
+
+var identityPath = epath.Join(epath.Class(0x01), epath.Instance(1))
+
+var vendorNames = map[uint16]string{
+	1:   "Rockwell Automation/Allen-Bradley",
+	5:   "Rockwell Automation/Reliance Electric",
+	26:  "Festo",
+	40:  "WAGO",
+	47:  "Omron",
+	48:  "Turck",
+	90:  "HMS Industrial Networks",
+	108: "Beckhoff Automation",
+	283: "Hilscher",
+	678: "Cognex",
+}
+
+var deviceTypeNames = map[uint16]string{
+	0x00: "Generic Device",
+	0x02: "AC Drive",
+	0x07: "General Purpose Discrete I/O",
+	0x0C: "Communications Adapter",
+	0x0E: "Programmable Logic Controller",
+	0x13: "DC Drive",
+	0x18: "Human-Machine Interface",
+	0x2B: "Generic Device (keyable)",
+}
+
+var extendedStatusNames = map[uint16]string{
+	0: "Self-testing or unknown",
+	1: "Firmware update in progress",
+	2: "At least one faulted I/O connection",
+	3: "No I/O connections established",
+	4: "Non-volatile configuration bad",
+	5: "Major fault",
+	6: "At least one I/O connection in run mode",
+	7: "At least one I/O connection established, all in idle mode",
+}
+
+// GetIdentity reads the Identity object of the device the client is
+// connected to.
+func (c *client) GetIdentity() (Identity, error) {
+	data, _, err := c.sendCIP(0x01, identityPath, nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	id, _, err := parseIdentity(data)
+	return id, err
+}
+
+// GetModuleIdentity reads the Identity object of the module in a slot of the
+// controller's chassis, routed through the backplane port.
+func (c *client) GetModuleIdentity(slot int) (Identity, error) {
+	data, _, err := c.sendUnconnected(0x01, identityPath, nil, backplaneRoute(uint8(slot)))
+	if err != nil {
+		return Identity{}, err
+	}
+	id, _, err := parseIdentity(data)
+	return id, err
+}
+
+func (id Identity) VendorName() string {
+	if name, ok := vendorNames[id.VendorID]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown vendor (%d)", id.VendorID)
+}
+
+func (id Identity) DeviceTypeName() string {
+	if name, ok := deviceTypeNames[id.DeviceType]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown device type (%#x)", id.DeviceType)
+}
+
+func (s IdentityStatus) Owned() bool {
+	return s&0x0001 != 0
+}
+
+func (s IdentityStatus) Configured() bool {
+	return s&0x0004 != 0
+}
+
+func (s IdentityStatus) ExtendedDeviceStatus() uint16 {
+	return uint16(s>>4) & 0x0F
+}
+
+func (s IdentityStatus) MinorRecoverableFault() bool {
+	return s&0x0100 != 0
+}
+
+func (s IdentityStatus) MinorUnrecoverableFault() bool {
+	return s&0x0200 != 0
+}
+
+func (s IdentityStatus) MajorRecoverableFault() bool {
+	return s&0x0400 != 0
+}
+
+func (s IdentityStatus) MajorUnrecoverableFault() bool {
+	return s&0x0800 != 0
+}
+
+func (s IdentityStatus) String() string {
+	flags := make([]string, 0)
+	if s.Owned() {
+		flags = append(flags, "owned")
+	}
+	if s.Configured() {
+		flags = append(flags, "configured")
+	}
+	if s.MinorRecoverableFault() {
+		flags = append(flags, "minor recoverable fault")
+	}
+	if s.MinorUnrecoverableFault() {
+		flags = append(flags, "minor unrecoverable fault")
+	}
+	if s.MajorRecoverableFault() {
+		flags = append(flags, "major recoverable fault")
+	}
+	if s.MajorUnrecoverableFault() {
+		flags = append(flags, "major unrecoverable fault")
+	}
+	if name, ok := extendedStatusNames[s.ExtendedDeviceStatus()]; ok {
+		flags = append(flags, name)
+	}
+	return strings.Join(flags, ", ")
+}
+
+func (r Revision) String() string {
+	return fmt.Sprintf("%d.%03d", r.Major, r.Minor)
+}
+
+// parseIdentity decodes the Identity object attributes shared by the
+// ListIdentity reply and Get_Attributes_All, starting at the vendor ID.
+func parseIdentity(data []byte) (Identity, int, error) {
+	var id Identity
+	var fixed struct {
+		VendorID     uint16
+		DeviceType   uint16
+		ProductCode  uint16
+		Revision     Revision
+		Status       IdentityStatus
+		SerialNumber uint32
+		NameLength   uint8
+	}
+	if e := binary.Read(bytes.NewReader(data), binary.LittleEndian, &fixed); e != nil {
+		return id, 0, errors.New("eip: identity is truncated")
+	}
+	n := binary.Size(fixed)
+	if len(data) < n+int(fixed.NameLength) {
+		return id, 0, errors.New("eip: identity product name is truncated")
+	}
+
+	id.VendorID = fixed.VendorID
+	id.DeviceType = fixed.DeviceType
+	id.ProductCode = fixed.ProductCode
+	id.Revision = fixed.Revision
+	id.Status = fixed.Status
+	id.SerialNumber = fixed.SerialNumber
+	id.ProductName = string(data[n : n+int(fixed.NameLength)])
+	return id, n + int(fixed.NameLength), nil
+}
@@ -0,0 +1,143 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+type KeepAliveMode int
+
+const (
+	// KeepAliveConnected reads the Identity status over the CIP connection,
+	// which also keeps the controller from timing the connection out.
+	KeepAliveConnected KeepAliveMode = iota
+	// KeepAliveNOP sends an encapsulation NOP, which keeps only the TCP
+	// session alive, and a connected read whenever the connection would
+	// otherwise time out. Transporters that cannot send without waiting for
+	// a reply fall back to KeepAliveConnected.
+	KeepAliveNOP
+)
+
+const encapNOP = 0x00
+
+// poster is implemented by transporters that can send a request the device
+// does not answer.
+type poster interface {
+	Post(request []byte) error
+}
+
+// idleCloser is implemented by transporters that drop the socket after a
+// quiet period.
+type idleCloser interface {
+	idleTimeout() time.Duration
+}
+
+func (c *client) BuildNOPRequest() []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct {
+		EIPCommand       uint16
+		EIPLength        uint16
+		EIPSessionHandle uint32
+		EIPStatus        uint32
+		EIPContext       uint64
+		EIPOptions       uint32
+	}{encapNOP, 0, GlobalOption.SessionHandle, 0, c.nextContext(), 0})
+	return buf.Bytes()
+}
+
+// Health returns nil while keep-alives succeed, and the last keep-alive error
+// once KeepAliveMaxFailures of them in a row have failed.
+func (c *client) Health() error {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	if c.failures < GlobalOption.KeepAliveMaxFailures || c.failures == 0 {
+		return nil
+	}
+	return fmt.Errorf("eip: %d keep-alives failed: %v", c.failures, c.healthErr)
+}
+
+// startKeepAlive sends a keep-alive whenever the client has been quiet for
+// half of KeepAliveInterval, so the gap between requests stays under the
+// interval. The interval has to be shorter than the transporter's idle
+// timeout, which then never closes the socket, and than the connection
+// timeout.
+func (c *client) startKeepAlive() error {
+	interval := GlobalOption.KeepAliveInterval
+	if interval <= 0 {
+		return nil
+	}
+	if t, ok := c.transporter.(idleCloser); ok && t.idleTimeout() > 0 && interval >= t.idleTimeout() {
+		return fmt.Errorf("eip: keep-alive interval %v is not shorter than the idle timeout %v", interval, t.idleTimeout())
+	}
+	if c.connectionTimeout > 0 && interval >= c.connectionTimeout {
+		return fmt.Errorf("eip: keep-alive interval %v is not shorter than the connection timeout %v", interval, c.connectionTimeout)
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	c.keepAlive, c.keepAliveDone = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			c.mu.Lock()
+			quiet := time.Since(c.lastRequest)
+			c.mu.Unlock()
+			if quiet >= interval/2 {
+				c.recordKeepAlive(c.sendKeepAlive())
+			}
+		}
+	}()
+	return nil
+}
+
+// stopKeepAlive waits for a keep-alive in flight, so none reaches the
+// transporter after Stop closes it.
+func (c *client) stopKeepAlive() {
+	if c.keepAlive != nil {
+		close(c.keepAlive)
+		<-c.keepAliveDone
+		c.keepAlive, c.keepAliveDone = nil, nil
+	}
+}
+
+func (c *client) sendKeepAlive() error {
+	if GlobalOption.KeepAliveMode == KeepAliveNOP {
+		if p, ok := c.transporter.(poster); ok {
+			request := c.BuildNOPRequest()
+			c.mu.Lock()
+			// a NOP never reaches the CIP connection
+			if c.connectionTimeout <= 0 || time.Since(c.lastConnected) < c.connectionTimeout/2 {
+				defer c.mu.Unlock()
+				c.lastRequest = time.Now()
+				return p.Post(request)
+			}
+			c.mu.Unlock()
+		}
+	}
+	_, _, err := c.GenericMessage(0x0E, 0x01, 1, 5, nil)
+	if _, ok := err.(*CIPError); ok {
+		// the controller answered, so the connection is alive
+		return nil
+	}
+	return err
+}
+
+func (c *client) recordKeepAlive(err error) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	if err == nil {
+		c.failures = 0
+		c.healthErr = nil
+		return
+	}
+	c.failures++
+	c.healthErr = err
+}
@@ -0,0 +1,187 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	encapListServices   = 0x04
+	encapListIdentity   = 0x63
+	encapListInterfaces = 0x64
+
+	cpfListServices = 0x100
+
+	serviceFlagCIPOverTCP  = 0x0020
+	serviceFlagClass01UDP  = 0x0100
+	listServicesNameLength = 16
+)
+
+type Service struct {
+	TypeID  uint16
+	Version uint16
+	Flags   uint16
+	Name    string
+}
+
+// Interface is an item of a ListInterfaces reply. The items are vendor
+// specific; they open with a version and capability flags like a service,
+// and Data holds whatever follows.
+type Interface struct {
+	TypeID  uint16
+	Version uint16
+	Flags   uint16
+	Data    []byte
+}
+
+func (s Service) SupportsCIPOverTCP() bool {
+	return s.Flags&serviceFlagCIPOverTCP != 0
+}
+
+func (s Service) SupportsClass01UDP() bool {
+	return s.Flags&serviceFlagClass01UDP != 0
+}
+
+func (i Interface) SupportsCIPOverTCP() bool {
+	return i.Flags&serviceFlagCIPOverTCP != 0
+}
+
+func (i Interface) SupportsClass01UDP() bool {
+	return i.Flags&serviceFlagClass01UDP != 0
+}
+
+func (c *client) ListServices() ([]Service, error) {
+	response, err := c.send(NewProtocolDataUnit(buildListRequest(encapListServices, c.nextContext())))
+	if err != nil {
+		return nil, err
+	}
+	return parseListServices(response.Data)
+}
+
+func (c *client) ListInterfaces() ([]Interface, error) {
+	response, err := c.send(NewProtocolDataUnit(buildListRequest(encapListInterfaces, c.nextContext())))
+	if err != nil {
+		return nil, err
+	}
+	return parseListInterfaces(response.Data)
+}
+
+// ListServicesUDP asks a single device for its encapsulation services over
+// UDP, which works before any TCP session is opened.
+func ListServicesUDP(address string, timeout time.Duration) ([]Service, error) {
+	reply, err := udpRequest(address, buildListRequest(encapListServices, 0), timeout)
+	if err != nil {
+		return nil, err
+	}
+	return parseListServices(reply)
+}
+
+func ListInterfacesUDP(address string, timeout time.Duration) ([]Interface, error) {
+	reply, err := udpRequest(address, buildListRequest(encapListInterfaces, 0), timeout)
+	if err != nil {
+		return nil, err
+	}
+	return parseListInterfaces(reply)
+}
+
+func buildListRequest(command uint16, context uint64) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct {
+		EIPCommand       uint16
+		EIPLength        uint16
+		EIPSessionHandle uint32
+		EIPStatus        uint32
+		EIPContext       uint64
+		EIPOptions       uint32
+	}{command, 0, 0, 0, context, 0})
+	return buf.Bytes()
+}
+
+func parseListServices(reply []byte) ([]Service, error) {
+	items, err := splitListItems(reply, encapListServices)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]Service, 0, len(items))
+	for _, item := range items {
+		if len(item.Data) < 4+listServicesNameLength {
+			return services, errors.New("eip: ListServices item is truncated")
+		}
+		services = append(services, Service{
+			TypeID:  item.TypeID,
+			Version: binary.LittleEndian.Uint16(item.Data[0:2]),
+			Flags:   binary.LittleEndian.Uint16(item.Data[2:4]),
+			Name:    strings.TrimRight(string(item.Data[4:4+listServicesNameLength]), "\x00"),
+		})
+	}
+	return services, nil
+}
+
+func parseListInterfaces(reply []byte) ([]Interface, error) {
+	items, err := splitListItems(reply, encapListInterfaces)
+	if err != nil {
+		return nil, err
+	}
+	interfaces := make([]Interface, 0, len(items))
+	for _, item := range items {
+		if len(item.Data) < 4 {
+			return interfaces, errors.New("eip: ListInterfaces item is truncated")
+		}
+		interfaces = append(interfaces, Interface{
+			TypeID:  item.TypeID,
+			Version: binary.LittleEndian.Uint16(item.Data[0:2]),
+			Flags:   binary.LittleEndian.Uint16(item.Data[2:4]),
+			Data:    item.Data[4:],
+		})
+	}
+	return interfaces, nil
+}
+
+// splitListItems checks the encapsulation header of a List* reply and
+// decodes the item list that follows.
+func splitListItems(reply []byte, command uint16) ([]CPFItem, error) {
+	h, data, err := ParseEncapsulation(reply)
+	if err != nil {
+		return nil, err
+	}
+	if h.Command != command {
+		return nil, fmt.Errorf("eip: expected a reply to command %#x, got %#x", command, h.Command)
+	}
+	if h.Status != 0 {
+		return nil, fmt.Errorf("eip: encapsulation status %#x", h.Status)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return ParseCPF(data)
+}
+
+func udpRequest(address string, request []byte, timeout time.Duration) ([]byte, error) {
+	if !strings.Contains(address, ":") {
+		address = address + ":" + strconv.Itoa(eipUDPPort)
+	}
+	conn, err := net.DialTimeout("udp4", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
@@ -0,0 +1,103 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"go_eip/epath"
+)
+
+// MessageStatus is the status part of a CIP reply.
+type MessageStatus struct {
+	Service  uint8
+	General  uint8
+	Extended []uint16
+}
+
+// OK reports success, counting a partial transfer as success since its data
+// is valid.
+func (s MessageStatus) OK() bool {
+	return s.General == 0x00 || s.General == 0x06
+}
+
+func (s MessageStatus) String() string {
+	text := fmt.Sprintf("0x%02X %s", s.General, ErrorText(int(s.General)))
+	for _, ext := range s.Extended {
+		text += fmt.Sprintf(" [0x%04X]", ext)
+	}
+	return text
+}
+
+type CIPError struct {
+	Status MessageStatus
+}
+
+func (e *CIPError) Error() string {
+	return fmt.Sprintf("eip: service 0x%02X failed: %s", e.Status.Service&0x7F, e.Status)
+}
+
+// GenericMessage sends an explicit request to class/instance/attribute over
+// the CIP connection and returns the reply data with its status; attribute 0
+// leaves the attribute out of the path. A failed status also returns a
+// *CIPError, along with whatever data the reply carried.
+func (c *client) GenericMessage(service uint8, class uint16, instance uint32, attribute uint16, data []byte) ([]byte, MessageStatus, error) {
+	reply, status, err := c.sendCIPMessage(service, c.buildLogicalPath(class, instance, attribute), data)
+	if err != nil {
+		return nil, status, err
+	}
+	if !status.OK() {
+		return reply, status, &CIPError{status}
+	}
+	return reply, status, nil
+}
+
+// GenericUnconnectedMessage is GenericMessage over SendRRData. A non-empty
+// route, such as {0x01, slot} for a backplane slot, wraps the request in an
+// Unconnected Send to the connection manager; without a route the request is
+// for the device at the other end of the session.
+func (c *client) GenericUnconnectedMessage(service uint8, class uint16, instance uint32, attribute uint16, data []byte, route []byte) ([]byte, MessageStatus, error) {
+	reply, status, err := c.sendUnconnectedMessage(service, c.buildLogicalPath(class, instance, attribute), data, route)
+	if err != nil {
+		return nil, status, err
+	}
+	if !status.OK() {
+		return reply, status, &CIPError{status}
+	}
+	return reply, status, nil
+}
+
+func (c *client) buildLogicalPath(class uint16, instance uint32, attribute uint16) []byte {
+	path := epath.Join(epath.Class(uint32(class)), epath.Instance(instance))
+	if attribute != 0 {
+		path = append(path, epath.Attribute(uint32(attribute))...)
+	}
+	return path
+}
+
+// buildUnconnectedRequest puts a CIP request straight into SendRRData for the
+// device the session is open with.
+func (c *client) buildUnconnectedRequest(service uint8, path []byte, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct{ a, b uint8 }{service, uint8(len(path) / 2)})
+	buf.Write(path)
+	buf.Write(data)
+	return append(c.buildEIPSendRRDataHeader(buf.Len()), buf.Bytes()...)
+}
+
+// parseMessageReply splits a CIP reply starting at its service byte into
+// status and data.
+func parseMessageReply(reply []byte) ([]byte, MessageStatus, error) {
+	if len(reply) < 4 {
+		return nil, MessageStatus{General: 0xFF}, fmt.Errorf("eip: CIP reply is truncated")
+	}
+	status := MessageStatus{Service: reply[0], General: reply[2]}
+	start := 4 + 2*int(reply[3])
+	if len(reply) < start {
+		return nil, status, fmt.Errorf("eip: CIP reply is truncated")
+	}
+	for i := 4; i < start; i += 2 {
+		status.Extended = append(status.Extended, binary.LittleEndian.Uint16(reply[i:i+2]))
+	}
+	return reply[start:], status, nil
+}
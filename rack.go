@@ -0,0 +1,51 @@
+package go_eip
+
+import (
+	"strings"
+)
+
+type SlotInfo struct {
+	Slot     int
+	Empty    bool
+	Status   uint8
+	Identity Identity
+	// Err is why the slot could not be read, if it was neither answered nor
+	// rejected by the route.
+	Err error
+}
+
+// Catalog is the catalog number the module reports at the start of its
+// product name, e.g. 1756-EN2T/D.
+func (s SlotInfo) Catalog() string {
+	if s.Empty {
+		return ""
+	}
+	fields := strings.Fields(s.Identity.ProductName)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// RackScan reads the Identity object of every slot from 0 to slots-1 through
+// the backplane port. Slots the route rejects are reported as empty along
+// with the CIP status; slots that fail any other way keep the error in Err
+// and the scan goes on with the next slot.
+func (c *client) RackScan(slots int) ([]SlotInfo, error) {
+	rack := make([]SlotInfo, 0, slots)
+	for slot := 0; slot < slots; slot++ {
+		info := SlotInfo{Slot: slot}
+		reply, status, err := c.sendUnconnectedMessage(0x01, identityPath, nil, backplaneRoute(uint8(slot)))
+		switch {
+		case err != nil:
+			info.Err = err
+		case !status.OK():
+			info.Empty = true
+			info.Status = status.General
+		default:
+			info.Identity, _, info.Err = parseIdentity(reply)
+		}
+		rack = append(rack, info)
+	}
+	return rack, nil
+}
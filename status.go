@@ -0,0 +1,103 @@
+package go_eip
+
+type ControllerMode int
+type Keyswitch int
+
+const (
+	ModeUnknown ControllerMode = iota
+	ModeRun
+	ModeProgram
+	ModeFaulted
+)
+
+const (
+	KeyswitchUnknown Keyswitch = iota
+	KeyswitchRun
+	KeyswitchProgram
+	KeyswitchRemote
+)
+
+type ControllerStatus struct {
+	Mode      ControllerMode
+	Keyswitch Keyswitch
+
+	MinorRecoverableFault   bool
+	MinorUnrecoverableFault bool
+	MajorRecoverableFault   bool
+	MajorUnrecoverableFault bool
+
+	Identity Identity
+}
+
+func (m ControllerMode) String() string {
+	switch m {
+	case ModeRun:
+		return "Run"
+	case ModeProgram:
+		return "Program"
+	case ModeFaulted:
+		return "Faulted"
+	default:
+		return "Unknown"
+	}
+}
+
+func (k Keyswitch) String() string {
+	switch k {
+	case KeyswitchRun:
+		return "Run"
+	case KeyswitchProgram:
+		return "Program"
+	case KeyswitchRemote:
+		return "Remote"
+	default:
+		return "Unknown"
+	}
+}
+
+func (s ControllerStatus) Faulted() bool {
+	return s.MajorRecoverableFault || s.MajorUnrecoverableFault
+}
+
+// GetControllerStatus decodes the Logix-specific bits of the Identity status
+// word: the extended device status carries Run/Program, bits 12-13 the
+// keyswitch. The major fault type and code are not reported: Logix keeps the
+// fault record in the Program object, which only GSV reaches, and exposes no
+// CIP attribute for it.
+func (c *client) GetControllerStatus() (ControllerStatus, error) {
+	id, err := c.GetIdentity()
+	if err != nil {
+		return ControllerStatus{}, err
+	}
+	return decodeControllerStatus(id), nil
+}
+
+func decodeControllerStatus(id Identity) ControllerStatus {
+	s := id.Status
+	status := ControllerStatus{
+		MinorRecoverableFault:   s.MinorRecoverableFault(),
+		MinorUnrecoverableFault: s.MinorUnrecoverableFault(),
+		MajorRecoverableFault:   s.MajorRecoverableFault(),
+		MajorUnrecoverableFault: s.MajorUnrecoverableFault(),
+		Identity:                id,
+	}
+
+	switch (uint16(s) >> 12) & 0x03 {
+	case 1:
+		status.Keyswitch = KeyswitchRun
+	case 2:
+		status.Keyswitch = KeyswitchProgram
+	case 3:
+		status.Keyswitch = KeyswitchRemote
+	}
+
+	switch {
+	case status.Faulted() || s.ExtendedDeviceStatus() == 5:
+		status.Mode = ModeFaulted
+	case s.ExtendedDeviceStatus() == 6:
+		status.Mode = ModeRun
+	case s.ExtendedDeviceStatus() == 7:
+		status.Mode = ModeProgram
+	}
+	return status
+}
@@ -0,0 +1,121 @@
+package go_eip
+
+import "strings"
+
+const (
+	tagListEntrySize = 23
+
+	symbolTypeSystem   = 0x1000
+	symbolTypeDimShift = 13
+	symbolTypeDimMask  = 0x03
+)
+
+type Tag struct {
+	TagName        string
+	InstanceID     uint32
+	DataType       uint8
+	SymbolType     uint16
+	ElementSize    uint16
+	Dimensions     [3]uint32
+	ExternalAccess uint8
+	// TemplateName is the structure's template name once it has been read.
+	TemplateName string
+}
+
+// tagListEntry is the fixed part of one Get_Instance_Attribute_List reply
+// entry, in the order BuildTagListRequest asks for the attributes.
+type tagListEntry struct {
+	InstanceID     uint32
+	SymbolType     uint16
+	ElementSize    uint16
+	Dimensions     [3]uint32
+	ExternalAccess uint8
+	NameLength     uint16
+}
+
+var systemTagPrefixes = []string{"__", "Map:", "Task:", "Cxn:", "Routine:"}
+
+var externalAccessNames = map[uint8]string{
+	0: "Read/Write",
+	1: "Reserved",
+	2: "Read Only",
+	3: "None",
+}
+
+func (t Tag) IsStructure() bool {
+	return t.SymbolType&symbolTypeStruct != 0
+}
+
+func (t Tag) IsArray() bool {
+	return t.ArrayDims() > 0
+}
+
+func (t Tag) ArrayDims() int {
+	return int(t.SymbolType>>symbolTypeDimShift) & symbolTypeDimMask
+}
+
+// IsSystem reports tags the controller reserves for itself, either by the
+// system bit of the symbol type or by one of the well-known name prefixes.
+func (t Tag) IsSystem() bool {
+	if t.SymbolType&symbolTypeSystem != 0 {
+		return true
+	}
+	name := t.TagName
+	if strings.HasPrefix(name, programPrefix) {
+		if pos := strings.Index(name, "."); pos >= 0 {
+			name = name[pos+1:]
+		}
+	}
+	for _, prefix := range systemTagPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProgram reports the controller-scope entries that name a program rather
+// than a tag.
+func (t Tag) IsProgram() bool {
+	return strings.HasPrefix(t.TagName, programPrefix) && !strings.Contains(t.TagName, ".")
+}
+
+// TemplateID is the template instance of a structure tag, zero otherwise.
+func (t Tag) TemplateID() uint16 {
+	if !t.IsStructure() {
+		return 0
+	}
+	return t.SymbolType & symbolTypeTemplate
+}
+
+func (t Tag) ExternalAccessName() string {
+	if name, ok := externalAccessNames[t.ExternalAccess]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// TypeName names the tag's data type, using the template name for structures
+// whose template has already been read.
+func (t Tag) TypeName() string {
+	if t.IsStructure() {
+		if t.TemplateName != "" {
+			return t.TemplateName
+		}
+		return "STRUCT"
+	}
+	if cip, ok := cipTypeMap[t.DataType]; ok {
+		return cip.TypeName
+	}
+	return "UNKNOWN"
+}
+
+func programNames(tagList []Tag) []string {
+	programs := make([]string, 0)
+	for _, t := range tagList {
+		if t.IsProgram() {
+			programs = append(programs, t.TagName)
+		}
+	}
+	return programs
+}
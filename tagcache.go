@@ -0,0 +1,131 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"go_eip/epath"
+)
+
+var ErrTagCacheStale = errors.New("eip: tag cache was saved for a different controller project")
+
+// controllerChangeAttributes are the Controller object (class 0xAC) attributes
+// whose values move whenever the project is downloaded or edited online.
+var controllerChangeAttributes = []uint16{1, 2, 3, 4, 10}
+
+type tagCache struct {
+	Signature   []byte               `json:"signature"`
+	DataTypes   map[string]uint8     `json:"dataTypes"`
+	Instances   map[string]uint32    `json:"instances"`
+	SymbolTypes map[string]uint16    `json:"symbolTypes"`
+	Templates   map[uint16]*Template `json:"templates"`
+}
+
+// SaveTagCache writes the cached tags under the signature of the project
+// they were learned from. A project changed since then empties the cache
+// before it is written.
+func (c *client) SaveTagCache(filename string) error {
+	if _, err := c.ProjectChanged(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(tagCache{
+		Signature:   c.signature,
+		DataTypes:   c.knownTags,
+		Instances:   c.knownInstances,
+		SymbolTypes: c.knownSymbolTypes,
+		Templates:   c.knownTemplates,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+func (c *client) LoadTagCache(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var cache tagCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return err
+	}
+
+	if _, err := c.ProjectChanged(); err != nil {
+		return err
+	}
+	if !bytes.Equal(c.signature, cache.Signature) {
+		return ErrTagCacheStale
+	}
+
+	for k, v := range cache.DataTypes {
+		c.knownTags[k] = v
+	}
+	for k, v := range cache.Instances {
+		c.knownInstances[k] = v
+	}
+	for k, v := range cache.SymbolTypes {
+		c.knownSymbolTypes[k] = v
+	}
+	for k, v := range cache.Templates {
+		c.knownTemplates[k] = v
+	}
+	return nil
+}
+
+// ProjectChanged compares the controller's change counters with the ones seen
+// last time and drops every cached tag, type and template when they differ.
+func (c *client) ProjectChanged() (bool, error) {
+	signature, err := c.readProjectSignature()
+	if err != nil {
+		return false, err
+	}
+	c.lastProjectCheck = time.Now()
+
+	if c.signature == nil {
+		c.signature = signature
+		return false, nil
+	}
+	if bytes.Equal(signature, c.signature) {
+		return false, nil
+	}
+	c.signature = signature
+	c.clearTagCache()
+	return true, nil
+}
+
+// checkProjectChange runs before the cache is used or filled. The first call
+// records the signature the cache belongs to; later calls compare it every
+// ProjectCheckInterval.
+func (c *client) checkProjectChange() {
+	interval := GlobalOption.ProjectCheckInterval
+	if !c.lastProjectCheck.IsZero() && (interval <= 0 || time.Since(c.lastProjectCheck) < interval) {
+		return
+	}
+	c.lastProjectCheck = time.Now()
+	c.ProjectChanged()
+}
+
+func (c *client) readProjectSignature() ([]byte, error) {
+	request := new(bytes.Buffer)
+	binary.Write(request, binary.LittleEndian, uint16(len(controllerChangeAttributes)))
+	binary.Write(request, binary.LittleEndian, controllerChangeAttributes)
+
+	data, _, err := c.sendCIP(0x03, epath.Join(epath.Class(0xAC), epath.Instance(1)), request.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (c *client) clearTagCache() {
+	c.knownTags = make(map[string]uint8)
+	c.knownInstances = make(map[string]uint32)
+	c.knownSymbolTypes = make(map[string]uint16)
+	c.knownTemplates = make(map[uint16]*Template)
+}
@@ -0,0 +1,255 @@
+package go_eip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go_eip/epath"
+)
+
+const (
+	programPrefix    = "Program:"
+	maxTagDimensions = 3
+	maxTagBit        = 63
+)
+
+type TagPath struct {
+	Program  string
+	Segments []TagSegment
+	Bit      int
+}
+
+type TagSegment struct {
+	Name    string
+	Indices []int
+}
+
+type TagSyntaxError struct {
+	Tag string
+	Pos int
+	Msg string
+}
+
+func (e *TagSyntaxError) Error() string {
+	return fmt.Sprintf("eip: invalid tag %q at offset %d: %s", e.Tag, e.Pos, e.Msg)
+}
+
+// ParseTagPath parses a Logix tag address such as
+// Program:MainProgram.Line[2].Stations[4,1].Count.3 into its program scope,
+// member segments with their subscripts and an optional trailing bit number.
+func ParseTagPath(tag string) (*TagPath, error) {
+	p := &tagParser{tag: tag}
+	path := &TagPath{Bit: -1}
+
+	if strings.HasPrefix(tag, programPrefix) {
+		p.pos = len(programPrefix)
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if len(programPrefix)+len(name) > epath.MaxSymbolLength {
+			return nil, &TagSyntaxError{Tag: tag, Pos: len(programPrefix), Msg: fmt.Sprintf("program name longer than %d characters", epath.MaxSymbolLength-len(programPrefix))}
+		}
+		path.Program = name
+		if p.done() {
+			return nil, p.errorf("missing tag name after program %q", name)
+		}
+		if err := p.expect('.'); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if p.done() {
+			return nil, p.errorf("missing tag name")
+		}
+		if isDigit(p.peek()) && len(path.Segments) > 0 {
+			bit, err := p.bit()
+			if err != nil {
+				return nil, err
+			}
+			path.Bit = bit
+			if !p.done() {
+				return nil, p.errorf("bit number must be the last element")
+			}
+			return path, nil
+		}
+
+		segment, err := p.segment()
+		if err != nil {
+			return nil, err
+		}
+		path.Segments = append(path.Segments, segment)
+		if p.done() {
+			return path, nil
+		}
+		if err := p.expect('.'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *TagPath) String() string {
+	var sb strings.Builder
+	if t.Program != "" {
+		sb.WriteString(programPrefix + t.Program + ".")
+	}
+	for i, s := range t.Segments {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(s.String())
+	}
+	if t.HasBit() {
+		sb.WriteString("." + strconv.Itoa(t.Bit))
+	}
+	return sb.String()
+}
+
+func (t *TagPath) HasBit() bool {
+	return t.Bit >= 0
+}
+
+// Symbol returns the name of the root symbol as it appears in the tag list.
+func (t *TagPath) Symbol() string {
+	if t.Program != "" {
+		return programPrefix + t.Program + "." + t.Segments[0].Name
+	}
+	return t.Segments[0].Name
+}
+
+func (t *TagPath) Last() *TagSegment {
+	return &t.Segments[len(t.Segments)-1]
+}
+
+// Element returns a copy of the path with the subscripts of its last segment
+// replaced by indices.
+func (t *TagPath) Element(indices ...int) *TagPath {
+	e := &TagPath{Program: t.Program, Bit: t.Bit}
+	e.Segments = append([]TagSegment{}, t.Segments...)
+	e.Last().Indices = indices
+	return e
+}
+
+func (s TagSegment) String() string {
+	if len(s.Indices) == 0 {
+		return s.Name
+	}
+	indices := make([]string, len(s.Indices))
+	for i, ind := range s.Indices {
+		indices[i] = strconv.Itoa(ind)
+	}
+	return s.Name + "[" + strings.Join(indices, ",") + "]"
+}
+
+type tagParser struct {
+	tag string
+	pos int
+}
+
+func (p *tagParser) done() bool {
+	return p.pos >= len(p.tag)
+}
+func (p *tagParser) peek() byte {
+	return p.tag[p.pos]
+}
+func (p *tagParser) errorf(format string, v ...interface{}) error {
+	return &TagSyntaxError{Tag: p.tag, Pos: p.pos, Msg: fmt.Sprintf(format, v...)}
+}
+func (p *tagParser) expect(b byte) error {
+	if p.done() {
+		return p.errorf("expected %q at end of tag", b)
+	}
+	if p.peek() != b {
+		return p.errorf("expected %q, found %q", b, p.peek())
+	}
+	p.pos++
+	return nil
+}
+func (p *tagParser) skipSpaces() {
+	for !p.done() && p.peek() == ' ' {
+		p.pos++
+	}
+}
+func (p *tagParser) identifier() (string, error) {
+	start := p.pos
+	if p.done() || !(isLetter(p.peek()) || p.peek() == '_') {
+		if p.done() {
+			return "", p.errorf("expected a name at end of tag")
+		}
+		return "", p.errorf("names must start with a letter or underscore, found %q", p.peek())
+	}
+	for !p.done() && (isLetter(p.peek()) || isDigit(p.peek()) || p.peek() == '_' || p.peek() == ':') {
+		p.pos++
+	}
+	if p.pos-start > epath.MaxSymbolLength {
+		return "", &TagSyntaxError{Tag: p.tag, Pos: start, Msg: "name longer than 255 characters"}
+	}
+	return p.tag[start:p.pos], nil
+}
+func (p *tagParser) segment() (TagSegment, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return TagSegment{}, err
+	}
+	segment := TagSegment{Name: name}
+	if p.done() || p.peek() != '[' {
+		return segment, nil
+	}
+
+	p.pos++
+	for {
+		p.skipSpaces()
+		start := p.pos
+		for !p.done() && isDigit(p.peek()) {
+			p.pos++
+		}
+		if start == p.pos {
+			if p.done() {
+				return segment, p.errorf("unterminated subscript")
+			}
+			return segment, p.errorf("expected an array index, found %q", p.peek())
+		}
+		ind, err := strconv.ParseUint(p.tag[start:p.pos], 10, 32)
+		if err != nil {
+			return segment, &TagSyntaxError{Tag: p.tag, Pos: start, Msg: "array index out of range"}
+		}
+		segment.Indices = append(segment.Indices, int(ind))
+		if len(segment.Indices) > maxTagDimensions {
+			return segment, &TagSyntaxError{Tag: p.tag, Pos: start, Msg: "arrays have at most 3 dimensions"}
+		}
+
+		p.skipSpaces()
+		if p.done() {
+			return segment, p.errorf("unterminated subscript")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return segment, nil
+		default:
+			return segment, p.errorf("expected ',' or ']', found %q", p.peek())
+		}
+	}
+}
+func (p *tagParser) bit() (int, error) {
+	start := p.pos
+	for !p.done() && isDigit(p.peek()) {
+		p.pos++
+	}
+	bit, err := strconv.Atoi(p.tag[start:p.pos])
+	if err != nil || bit > maxTagBit {
+		return 0, &TagSyntaxError{Tag: p.tag, Pos: start, Msg: "bit number must be between 0 and 63"}
+	}
+	return bit, nil
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -1,6 +1,9 @@
 package go_eip
 
 import (
+	"encoding/binary"
+	"errors"
+	"io"
 	"log"
 	"net"
 	"strconv"
@@ -18,6 +21,10 @@ const (
 	connectionTypeBasic = 3
 )
 
+// errNotConnected is returned once the idle timer or Close has dropped the
+// socket.
+var errNotConnected = errors.New("eip: not connected")
+
 type tcpPackager struct{}
 type tcpTransporter struct {
 	Address     string
@@ -47,12 +54,15 @@ func NewTCPClientHandler(address string) *tCPClientHandler {
 }
 
 func (t *tcpPackager) Verify(request []byte, response []byte) (err error) {
-	return
+	return verifyReply(request, response)
 }
 
 func (t *tcpTransporter) Send(request []byte) ([]byte, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil, errNotConnected
+	}
 	t.lastActivity = time.Now()
 	t.startCloseTimer()
 
@@ -68,13 +78,41 @@ func (t *tcpTransporter) Send(request []byte) ([]byte, error) {
 	if _, err := t.conn.Write(request); err != nil {
 		return nil, err
 	}
-	data := make([]byte, tcpMaxLength)
+	// read the header first; its length says how much data follows
+	data := make([]byte, encapHeaderSize, tcpMaxLength)
+	if _, err := io.ReadFull(t.conn, data); err != nil {
+		return nil, err
+	}
+	length := int(binary.LittleEndian.Uint16(data[2:4]))
+	data = append(data, make([]byte, length)...)
+	if _, err := io.ReadFull(t.conn, data[encapHeaderSize:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Post writes a request that gets no reply, such as a NOP.
+func (t *tcpTransporter) Post(request []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return errNotConnected
+	}
+	t.lastActivity = time.Now()
+	t.startCloseTimer()
 
-	l, e := t.conn.Read(data)
-	if e != nil {
-		return nil, e
+	var timeout time.Time
+	if t.Timeout > 0 {
+		timeout = t.lastActivity.Add(t.Timeout)
 	}
-	return data[:l], nil
+	if err := t.conn.SetDeadline(timeout); err != nil {
+		return err
+	}
+	_, err := t.conn.Write(request)
+	return err
+}
+func (t *tcpTransporter) idleTimeout() time.Duration {
+	return t.IdleTimeout
 }
 func (t *tcpTransporter) Close() error {
 	t.mu.Lock()
@@ -0,0 +1,276 @@
+package go_eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go_eip/epath"
+)
+
+const (
+	templateClass     = 0x6C
+	templateChunkSize = 400
+	// templates hold at most a few hundred members of 8 bytes plus names
+	templateMaxDefinition = 1 << 16
+
+	symbolTypeStruct   = 0x8000
+	symbolTypeTemplate = 0x0FFF
+)
+
+type Template struct {
+	Instance uint16
+	Handle   uint16
+	Name     string
+	Size     uint32
+	Members  []TemplateMember
+}
+
+type TemplateMember struct {
+	Name   string
+	Info   uint16
+	Type   uint16
+	Offset uint32
+}
+
+type stringType struct {
+	Handle     uint16
+	Size       uint32
+	Capacity   int
+	LenOffset  uint32
+	DataOffset uint32
+}
+
+// stockStringHandle identifies the predefined STRING: a DINT LEN and 82
+// characters of DATA in 88 bytes.
+const stockStringHandle = 0x0FCE
+
+var stockString = stringType{Handle: stockStringHandle, Size: 88, Capacity: 82, LenOffset: 0, DataOffset: 4}
+
+func (t *Template) Member(name string) *TemplateMember {
+	for i := range t.Members {
+		if strings.EqualFold(t.Members[i].Name, name) {
+			return &t.Members[i]
+		}
+	}
+	return nil
+}
+
+// stringLayout reports where LEN and DATA live in a Logix string type, so
+// STRING_20, STRING_200 and other user string types can be written exactly.
+func (t *Template) stringLayout() (*stringType, bool) {
+	length, data := t.Member("LEN"), t.Member("DATA")
+	if length == nil || data == nil || length.Type&0xFF != 196 || data.Type&0xFF != 194 {
+		return nil, false
+	}
+	return &stringType{
+		Handle:     t.Handle,
+		Size:       t.Size,
+		Capacity:   int(data.Info),
+		LenOffset:  length.Offset,
+		DataOffset: data.Offset,
+	}, true
+}
+
+func (c *client) GetTemplate(instance uint16) (*Template, error) {
+	c.checkProjectChange()
+	if t, ok := c.knownTemplates[instance]; ok {
+		return t, nil
+	}
+
+	path := epath.Join(epath.Class(templateClass), epath.Instance(uint32(instance)))
+
+	attributes := new(bytes.Buffer)
+	binary.Write(attributes, binary.LittleEndian, struct {
+		AttributeCount  uint16
+		DefinitionSize  uint16
+		StructureSize   uint16
+		MemberCount     uint16
+		StructureHandle uint16
+	}{4, 4, 5, 2, 1})
+	data, _, err := c.sendCIP(0x03, path, attributes.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		AttributeCount       uint16
+		DefinitionSizeID     uint16
+		DefinitionSizeStatus uint16
+		DefinitionSize       uint32
+		StructureSizeID      uint16
+		StructureSizeStatus  uint16
+		StructureSize        uint32
+		MemberCountID        uint16
+		MemberCountStatus    uint16
+		MemberCount          uint16
+		HandleID             uint16
+		HandleStatus         uint16
+		Handle               uint16
+	}
+	if e := binary.Read(bytes.NewReader(data), binary.LittleEndian, &reply); e != nil {
+		return nil, fmt.Errorf("eip: template %d attributes: %v", instance, e)
+	}
+	if reply.DefinitionSizeStatus != 0 || reply.StructureSizeStatus != 0 ||
+		reply.MemberCountStatus != 0 || reply.HandleStatus != 0 {
+		return nil, fmt.Errorf("eip: template %d attributes are not readable", instance)
+	}
+
+	if reply.DefinitionSize > templateMaxDefinition {
+		return nil, fmt.Errorf("eip: template %d definition of %d words is implausible", instance, reply.DefinitionSize)
+	}
+
+	// the definition is four bytes per word minus a header the read does not return
+	definition := make([]byte, 0, reply.DefinitionSize*4)
+	total := int(reply.DefinitionSize)*4 - 23
+	for len(definition) < total {
+		chunk := total - len(definition)
+		if chunk > templateChunkSize {
+			chunk = templateChunkSize
+		}
+		request := new(bytes.Buffer)
+		binary.Write(request, binary.LittleEndian, struct {
+			Offset uint32
+			Length uint16
+		}{uint32(len(definition)), uint16(chunk)})
+		data, status, err := c.sendCIP(0x4C, path, request.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		definition = append(definition, data...)
+		if status == 0 {
+			break
+		}
+	}
+
+	t, err := parseTemplate(definition, int(reply.MemberCount))
+	if err != nil {
+		return nil, err
+	}
+	t.Instance = instance
+	t.Handle = reply.Handle
+	t.Size = reply.StructureSize
+	c.knownTemplates[instance] = t
+	return t, nil
+}
+
+func parseTemplate(definition []byte, memberCount int) (*Template, error) {
+	if len(definition) < memberCount*8 {
+		return nil, errors.New("eip: template definition is shorter than its member list")
+	}
+	t := &Template{Members: make([]TemplateMember, memberCount)}
+	for i := range t.Members {
+		m := definition[i*8 : i*8+8]
+		t.Members[i].Info = binary.LittleEndian.Uint16(m[0:2])
+		t.Members[i].Type = binary.LittleEndian.Uint16(m[2:4])
+		t.Members[i].Offset = binary.LittleEndian.Uint32(m[4:8])
+	}
+
+	names := strings.Split(string(definition[memberCount*8:]), "\x00")
+	if len(names) < memberCount+1 {
+		return nil, errors.New("eip: template definition is missing member names")
+	}
+	t.Name = names[0]
+	if pos := strings.Index(t.Name, ";"); pos >= 0 {
+		t.Name = t.Name[:pos]
+	}
+	for i := range t.Members {
+		t.Members[i].Name = names[i+1]
+	}
+	return t, nil
+}
+
+// resolveSymbolType walks the templates from the root symbol of path down to
+// its last member and returns that member's symbol type.
+func (c *client) resolveSymbolType(path *TagPath) (uint16, bool) {
+	symbolType, ok := c.knownSymbolTypes[path.Symbol()]
+	if !ok {
+		return 0, false
+	}
+	for _, segment := range path.Segments[1:] {
+		if symbolType&symbolTypeStruct == 0 {
+			return 0, false
+		}
+		t, err := c.GetTemplate(symbolType & symbolTypeTemplate)
+		if err != nil {
+			return 0, false
+		}
+		m := t.Member(segment.Name)
+		if m == nil {
+			return 0, false
+		}
+		symbolType = m.Type
+	}
+	return symbolType, true
+}
+
+// knownMember finds name in the already read template of a structure type
+// and returns its member ID, which is its position in the template, and its
+// symbol type.
+func (c *client) knownMember(symbolType uint16, name string) (int, uint16, bool) {
+	if symbolType&symbolTypeStruct == 0 {
+		return -1, 0, false
+	}
+	t, ok := c.knownTemplates[symbolType&symbolTypeTemplate]
+	if !ok {
+		return -1, 0, false
+	}
+	for i := range t.Members {
+		if strings.EqualFold(t.Members[i].Name, name) {
+			return i, t.Members[i].Type, true
+		}
+	}
+	return -1, 0, false
+}
+
+func (c *client) getStringType(path *TagPath) (*stringType, error) {
+	if symbolType, ok := c.resolveSymbolType(path); ok {
+		if symbolType&symbolTypeStruct == 0 {
+			return nil, errors.New("eip: " + path.String() + " is not a string")
+		}
+		t, err := c.GetTemplate(symbolType & symbolTypeTemplate)
+		if err != nil {
+			return nil, err
+		}
+		if st, ok := t.stringLayout(); ok {
+			return st, nil
+		}
+		return nil, errors.New("eip: " + path.String() + " is a " + t.Name + ", not a string")
+	}
+
+	// without the tag list identify the type by the structure handle a read
+	// returns; a partial read does not tell how much of it is string data
+	response, err := c.send(NewProtocolDataUnit(c.BuildReadIOIRequest(path, false, 1)))
+	if err != nil {
+		return nil, err
+	}
+	data, status, err := cipReplyData(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	if status.General != 0 {
+		return nil, errors.New(ErrorText(int(status.General)))
+	}
+	if len(data) < 4 || data[0] != 160 {
+		return nil, errors.New("eip: " + path.String() + " is not a string")
+	}
+	handle := binary.LittleEndian.Uint16(data[2:4])
+	for _, t := range c.knownTemplates {
+		if t.Handle != handle {
+			continue
+		}
+		if st, ok := t.stringLayout(); ok {
+			return st, nil
+		}
+		return nil, errors.New("eip: " + path.String() + " is a " + t.Name + ", not a string")
+	}
+	if handle == stockStringHandle {
+		st := stockString
+		return &st, nil
+	}
+	return nil, fmt.Errorf("eip: the structure of %s is not known, read the tag list first", path)
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+	"testing"
+)
+
+// boolArrayPLC holds one BOOL array tag as DWORDs and records the element
+// and masks of every read-modify-write.
+type boolArrayPLC struct {
+	FakePLC
+	words    []uint32
+	elements []int
+	masks    [][2]uint32
+	partial  int
+}
+
+func newBoolArrayPLC(words int) *boolArrayPLC {
+	p := &boolArrayPLC{words: make([]uint32, words)}
+	p.Handler = func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		element := int(path[len(path)-1])
+		switch service {
+		case 0x52:
+			p.partial++
+			return 0, nil, []byte{0xD3, 0x00, 0x00, 0x00, 0x00, 0x00}
+		case 0x4C:
+			reply := []byte{0xD3, 0x00}
+			for i := 0; i < int(binary.LittleEndian.Uint16(data)); i++ {
+				reply = binary.LittleEndian.AppendUint32(reply, p.words[element+i])
+			}
+			return 0, nil, reply
+		case 0x4E:
+			orMask, andMask := binary.LittleEndian.Uint32(data[2:6]), binary.LittleEndian.Uint32(data[6:10])
+			p.elements = append(p.elements, element)
+			p.masks = append(p.masks, [2]uint32{orMask, andMask})
+			p.words[element] = p.words[element]&andMask | orMask
+			return 0, nil, nil
+		}
+		return 0x08, nil, nil
+	}
+	return p
+}
+
+func TestWriteBoolArrayAcrossWords(t *testing.T) {
+	plc := newBoolArrayPLC(4)
+	client := go_eip.NewClient(plc, 0)
+
+	values := make([]bool, 70)
+	for i := range values {
+		values[i] = i%3 == 0
+	}
+	AssertEquals(t, client.WriteBoolArray("B[29]", values), nil)
+
+	// bits 29..98 span the top of word 0, words 1 and 2 and the bottom of word 3
+	AssertEquals(t, len(plc.elements), 4)
+	want := make([][2]uint32, 4)
+	for i := range want {
+		want[i] = [2]uint32{0, 0xFFFFFFFF}
+	}
+	for i, v := range values {
+		bit := 29 + i
+		if v {
+			want[bit/32][0] |= 1 << uint(bit%32)
+		} else {
+			want[bit/32][1] &^= 1 << uint(bit%32)
+		}
+	}
+	for i := range want {
+		AssertEquals(t, plc.elements[i], i)
+		AssertEquals(t, plc.masks[i], want[i])
+	}
+	AssertEquals(t, plc.masks[0][1], uint32(0x1FFFFFFF|1<<29))
+
+	read, e := client.ReadBoolArray("B[29]", len(values))
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(read), len(values))
+	for i := range values {
+		AssertEquals(t, read[i], values[i])
+	}
+
+	AssertEquals(t, client.Write("B[37]", true), nil)
+	AssertEquals(t, plc.elements[4], 1)
+	AssertEquals(t, plc.masks[4], [2]uint32{1 << 5, 0xFFFFFFFF})
+}
+
+func TestWriteBoolArrayElementValues(t *testing.T) {
+	plc := newBoolArrayPLC(4)
+	client := go_eip.NewClient(plc, 0)
+
+	AssertEquals(t, client.Write("Flags", true) != nil, true)
+	AssertEquals(t, client.Write("Flags[37]", 1) != nil, true)
+	AssertEquals(t, len(plc.elements), 0)
+}
+
+func TestMultiReadBoolArrayFromTagList(t *testing.T) {
+	var request []byte
+	plc := newBoolArrayPLC(4)
+	plc.Symbols = map[string][]FakeSymbol{"": {{1, "Flags", 0x2000 | 0xD3}}}
+	handler := plc.Handler
+	plc.Handler = func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		if service == 0x0A {
+			request = append([]byte{}, data...)
+			return 0, nil, []byte{0x01, 0x00, 0x04, 0x00, 0xCC, 0x00, 0x00, 0x00, 0xD3, 0x00, 0x20, 0x00, 0x00, 0x00}
+		}
+		return handler(service, path, data)
+	}
+	client := go_eip.NewClient(plc, 0)
+	_, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+
+	reply, e := client.MultiRead("Flags[37]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, reply["Flags[37]"], true)
+	AssertEquals(t, plc.partial, 0)
+	AssertEquals(t, bytes.HasSuffix(request, []byte{0x28, 0x01, 0x01, 0x00}), true)
+}
@@ -12,17 +12,36 @@ import (
 func ClientTestAll(t *testing.T, client go_eip.Client) {
 	//ClientTestGetPLCTime(t, client)
 	//ClientTestReadWriteString(t, client)
+	//ClientTestReadWriteCustomString(t, client)
 	ClientTestReadWriteSINT(t, client)
 	ClientTestReadWriteBit(t, client)
+	ClientTestReadWriteBoolArray(t, client)
+	ClientTestWriteBits(t, client)
 	ClientTestMultiRead(t, client)
+	ClientTestReadWriteMultiDimArray(t, client)
+	ClientTestInstanceAddressing(t, client)
+	ClientTestGetTagList(t, client)
+	ClientTestListTags(t, client)
+	ClientTestTagCache(t, client)
+	ClientTestListServices(t, client)
 }
 
 func ClientTestGetPLCTime(t *testing.T, client go_eip.Client) {
 	r, _ := client.GetPLCTime()
 	log.Println(r.Format(time.RFC3339))
-	client.SetPLCTime(time.Now())
+	want := time.Date(2020, 2, 29, 12, 30, 0, 0, time.UTC)
+	AssertEquals(t, client.SetPLCTime(want), nil)
 	r, _ = client.GetPLCTime()
 	log.Println(r.Format(time.RFC3339))
+	AssertEquals(t, r.Sub(want) < 5*time.Second, true)
+	client.SetPLCTime(time.Now())
+
+	z, e := client.GetPLCTimeZone()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, client.SetPLCTimeZone(z), nil)
+	local, e := client.GetPLCLocalTime()
+	AssertEquals(t, e, nil)
+	log.Println(local.Format(time.RFC3339))
 }
 func ClientTestReadWriteString(t *testing.T, client go_eip.Client) {
 	client.Write("Program:MainProgram.string", "abcd")
@@ -30,6 +49,18 @@ func ClientTestReadWriteString(t *testing.T, client go_eip.Client) {
 	AssertEquals(t, e, nil)
 	AssertEquals(t, r, "abcd")
 }
+func ClientTestReadWriteCustomString(t *testing.T, client go_eip.Client) {
+	_, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+
+	AssertEquals(t, client.Write("Program:MainProgram.string20", "twenty characters..."), nil)
+	r, e := client.Read("Program:MainProgram.string20")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, "twenty characters...")
+
+	e = client.Write("Program:MainProgram.string20", "twenty-one characters")
+	AssertEquals(t, e != nil, true)
+}
 func ClientTestReadWriteSINT(t *testing.T, client go_eip.Client) {
 	client.Write("Program:MainProgram.sint", 12)
 	r, e := client.Read("Program:MainProgram.sint")
@@ -47,6 +78,112 @@ func ClientTestReadWriteBit(t *testing.T, client go_eip.Client) {
 	r1, e1 = client.Read("Program:MainProgram.third.15")
 	AssertEquals(t, r1 == true, true)
 }
+func ClientTestWriteBits(t *testing.T, client go_eip.Client) {
+	AssertEquals(t, client.Write("Program:MainProgram.sint", 0x0F), nil)
+	AssertEquals(t, client.WriteBits("Program:MainProgram.sint", map[int]bool{0: false, 7: true}), nil)
+	r, e := client.Read("Program:MainProgram.sint")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, uint8(0x8E))
+
+	AssertEquals(t, client.WriteMasked("Program:MainProgram.sint", 0x01, 0x0F), nil)
+	r, e = client.Read("Program:MainProgram.sint")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, uint8(0x0F))
+
+	AssertEquals(t, client.Write("Program:MainProgram.sint.3", false), nil)
+	r, e = client.Read("Program:MainProgram.sint.3")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, false)
+}
+func ClientTestReadWriteBoolArray(t *testing.T, client go_eip.Client) {
+	AssertEquals(t, client.Write("Program:MainProgram.bools[37]", true), nil)
+	r, e := client.Read("Program:MainProgram.bools[37]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, true)
+
+	values := []bool{true, false, true, true, false, false, true, false, true, true}
+	AssertEquals(t, client.WriteBoolArray("Program:MainProgram.bools[28]", values), nil)
+	r2, e := client.ReadBoolArray("Program:MainProgram.bools[28]", len(values))
+	AssertEquals(t, e, nil)
+	for i := range values {
+		AssertEquals(t, values[i], r2[i])
+	}
+}
+func ClientTestReadWriteMultiDimArray(t *testing.T, client go_eip.Client) {
+	AssertEquals(t, client.Write("Program:MainProgram.matrix[2,3]", 23), nil)
+	AssertEquals(t, client.Write("Program:MainProgram.matrix[3,2]", 32), nil)
+	r, e := client.Read("Program:MainProgram.matrix[2,3]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, uint32(23))
+	r, e = client.Read("Program:MainProgram.matrix[3,2]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, uint32(32))
+}
+func ClientTestInstanceAddressing(t *testing.T, client go_eip.Client) {
+	_, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+
+	go_eip.GlobalOption.InstanceAddressing = true
+	defer func() { go_eip.GlobalOption.InstanceAddressing = false }()
+
+	AssertEquals(t, client.Write("Program:MainProgram.sint", 21), nil)
+	r, e := client.Read("Program:MainProgram.sint")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, r, uint8(21))
+}
+func ClientTestGetTagList(t *testing.T, client go_eip.Client) {
+	tags, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+	for _, tag := range tags {
+		if tag.TagName == "Program:MainProgram.matrix" {
+			AssertEquals(t, tag.IsArray(), true)
+			AssertEquals(t, tag.ArrayDims(), 2)
+			AssertEquals(t, tag.TypeName(), "DINT")
+		}
+		log.Println(tag.TagName, tag.TypeName(), tag.Dimensions, tag.ExternalAccessName(), tag.IsSystem())
+	}
+}
+func ClientTestListTags(t *testing.T, client go_eip.Client) {
+	programs, e := client.ListPrograms()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(programs) > 0, true)
+
+	for _, p := range programs {
+		tags, e := client.ListTags(p, true)
+		AssertEquals(t, e, nil)
+		for _, tag := range tags {
+			AssertEquals(t, strings.HasPrefix(tag.TagName, p+"."), true)
+			AssertEquals(t, tag.IsSystem(), false)
+		}
+	}
+
+	tags, e := client.ListTags("", true)
+	AssertEquals(t, e, nil)
+	for _, tag := range tags {
+		AssertEquals(t, tag.IsProgram(), false)
+	}
+}
+func ClientTestTagCache(t *testing.T, client go_eip.Client) {
+	_, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+
+	filename := t.TempDir() + "/tags.json"
+	AssertEquals(t, client.SaveTagCache(filename), nil)
+	AssertEquals(t, client.LoadTagCache(filename), nil)
+
+	changed, e := client.ProjectChanged()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, changed, false)
+}
+func ClientTestListServices(t *testing.T, client go_eip.Client) {
+	services, e := client.ListServices()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(services) > 0, true)
+	AssertEquals(t, services[0].SupportsCIPOverTCP(), true)
+
+	_, e = client.ListInterfaces()
+	AssertEquals(t, e, nil)
+}
 func ClientTestMultiRead(t *testing.T, client go_eip.Client) {
 	log.Println(client.MultiRead(
 		"Program:MainProgram.first",
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"errors"
+	"go_eip"
+	"testing"
+	"time"
+)
+
+type stubClock struct {
+	go_eip.Client
+	offset time.Duration
+	now    *time.Time
+	set    []time.Time
+	err    error
+}
+
+func (c *stubClock) GetPLCTime() (time.Time, error) {
+	if c.err != nil {
+		return time.Time{}, c.err
+	}
+	*c.now = c.now.Add(10 * time.Millisecond)
+	return c.now.Add(c.offset), nil
+}
+
+func (c *stubClock) SetPLCTime(t time.Time) error {
+	c.set = append(c.set, t)
+	c.offset = 0
+	return nil
+}
+
+func TestClockSync(t *testing.T) {
+	now := time.Date(2024, 3, 1, 2, 30, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(10 * time.Millisecond)
+		return now
+	}
+	plc := &stubClock{offset: 3 * time.Second, now: &now}
+
+	var samples []go_eip.DriftSample
+	sync := go_eip.NewClockSync(plc, go_eip.ClockSyncOptions{
+		Name:      "line1",
+		Threshold: time.Second,
+		Window:    &go_eip.MaintenanceWindow{Start: 22 * time.Hour, End: 4 * time.Hour},
+		OnDrift:   func(s go_eip.DriftSample) { samples = append(samples, s) },
+		Now:       clock,
+	})
+
+	s, err := sync.Sync()
+	AssertEquals(t, err, nil)
+	AssertEquals(t, s.RoundTrip, 20*time.Millisecond)
+	AssertEquals(t, s.Drift, 3*time.Second)
+	AssertEquals(t, s.Corrected, true)
+	AssertEquals(t, len(plc.set), 1)
+
+	s, _ = sync.Sync()
+	AssertEquals(t, s.Drift, time.Duration(0))
+	AssertEquals(t, s.Corrected, false)
+	AssertEquals(t, len(samples), 2)
+
+	// outside the window drift is only reported
+	now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	plc.offset = -2 * time.Second
+	s, _ = sync.Sync()
+	AssertEquals(t, s.Drift, -2*time.Second)
+	AssertEquals(t, s.Corrected, false)
+	AssertEquals(t, len(plc.set), 1)
+
+	plc.err = errors.New("timeout")
+	_, err = sync.Sync()
+	AssertEquals(t, err, plc.err)
+	AssertEquals(t, samples[len(samples)-1].Err, plc.err)
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	day := func(h, m int) time.Time { return time.Date(2024, 3, 1, h, m, 0, 0, time.UTC) }
+	w := &go_eip.MaintenanceWindow{Start: 22 * time.Hour, End: 4 * time.Hour}
+	AssertEquals(t, w.Contains(day(23, 0)), true)
+	AssertEquals(t, w.Contains(day(3, 59)), true)
+	AssertEquals(t, w.Contains(day(4, 0)), false)
+	AssertEquals(t, w.Contains(day(12, 0)), false)
+
+	w = &go_eip.MaintenanceWindow{Start: time.Hour, End: 2 * time.Hour}
+	AssertEquals(t, w.Contains(day(1, 30)), true)
+	AssertEquals(t, w.Contains(day(2, 30)), false)
+
+	var none *go_eip.MaintenanceWindow
+	AssertEquals(t, none.Contains(day(12, 0)), true)
+}
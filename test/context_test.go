@@ -0,0 +1,47 @@
+package test
+
+import (
+	"encoding/binary"
+	"go_eip"
+	"testing"
+)
+
+type contextPLC struct {
+	FakePLC
+	contexts []uint64
+}
+
+func (p *contextPLC) Send(request []byte) ([]byte, error) {
+	p.contexts = append(p.contexts, binary.LittleEndian.Uint64(request[12:20]))
+	return p.FakePLC.Send(request)
+}
+
+func TestSenderContext(t *testing.T) {
+	plc := &contextPLC{FakePLC: FakePLC{Modules: map[uint8]FakeModule{
+		0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"},
+	}}}
+	client := go_eip.NewClient(plc, 0)
+	client.GetIdentity()
+	client.GetIdentity()
+
+	AssertEquals(t, len(plc.contexts), 4)
+	seen := make(map[uint64]bool)
+	for _, c := range plc.contexts {
+		seen[c] = true
+	}
+	AssertEquals(t, len(seen), 4)
+	AssertEquals(t, client.LastContext(), plc.contexts[3])
+
+	next := uint64(0xABC0)
+	client.SetContextGenerator(func() uint64 {
+		next++
+		return next
+	})
+	_, e := client.GetIdentity()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, plc.contexts[4], uint64(0xABC1))
+	AssertEquals(t, client.LastContext(), uint64(0xABC1))
+
+	other := go_eip.NewContextGenerator()
+	AssertEquals(t, other()+1, other())
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+	"net"
+	"testing"
+	"time"
+)
+
+func listIdentityReply(ip net.IP, serial uint32, name string) []byte {
+	item := new(bytes.Buffer)
+	binary.Write(item, binary.LittleEndian, uint16(1))
+	binary.Write(item, binary.BigEndian, struct {
+		Family uint16
+		Port   uint16
+	}{2, 44818})
+	item.Write(ip.To4())
+	item.Write(make([]byte, 8))
+	binary.Write(item, binary.LittleEndian, struct {
+		VendorID     uint16
+		DeviceType   uint16
+		ProductCode  uint16
+		Major, Minor uint8
+		Status       uint16
+		SerialNumber uint32
+		NameLength   uint8
+	}{1, 0x0E, 0x5F, 32, 11, 0x3060, serial, uint8(len(name))})
+	item.WriteString(name)
+	item.WriteByte(3)
+
+	reply := new(bytes.Buffer)
+	binary.Write(reply, binary.LittleEndian, struct {
+		Command uint16
+		Length  uint16
+		Session uint32
+		Status  uint32
+		Context uint64
+		Options uint32
+		Count   uint16
+		Type    uint16
+		ItemLen uint16
+	}{0x63, uint16(6 + item.Len()), 0, 0, 0, 0, 1, 0x0C, uint16(item.Len())})
+	reply.Write(item.Bytes())
+	return reply.Bytes()
+}
+
+func TestDiscover(t *testing.T) {
+	responder, e := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	AssertEquals(t, e, nil)
+	defer responder.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		n, from, err := responder.ReadFromUDP(buf)
+		if err != nil || n < 24 || binary.LittleEndian.Uint16(buf[0:2]) != 0x63 {
+			return
+		}
+		responder.WriteToUDP(listIdentityReply(net.IPv4(192, 168, 1, 10), 0xC0FFEE, "1769-L33ER/A LOGIX5333ER"), from)
+		responder.WriteToUDP(listIdentityReply(net.IPv4(0, 0, 0, 0), 0xBEEF, "1756-EN2T/D"), from)
+		responder.WriteToUDP(listIdentityReply(net.IPv4(192, 168, 1, 10), 0xC0FFEE, "1769-L33ER/A LOGIX5333ER"), from)
+	}()
+
+	identities, e := go_eip.Discover(responder.LocalAddr().String(), 300*time.Millisecond)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(identities), 2)
+
+	AssertEquals(t, identities[0].ProductName, "1769-L33ER/A LOGIX5333ER")
+	AssertEquals(t, identities[0].IP.String(), "192.168.1.10")
+	AssertEquals(t, identities[0].SerialNumber, uint32(0xC0FFEE))
+	AssertEquals(t, identities[0].Revision.String(), "32.011")
+	AssertEquals(t, identities[0].ProductCode, uint16(0x5F))
+	AssertEquals(t, identities[0].State, uint8(3))
+
+	AssertEquals(t, identities[1].ProductName, "1756-EN2T/D")
+	AssertEquals(t, identities[1].IP.String(), "127.0.0.1")
+}
+
+func TestClientDiscoverAddress(t *testing.T) {
+	responder, e := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	AssertEquals(t, e, nil)
+	defer responder.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		_, from, err := responder.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		responder.WriteToUDP(listIdentityReply(net.IPv4(10, 0, 2, 7), 0xBEEF, "1756-EN2T/D"), from)
+	}()
+
+	client := go_eip.NewClient(&FakePLC{}, 0)
+	identities, e := client.Discover(responder.LocalAddr().String(), 300*time.Millisecond)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(identities), 1)
+	AssertEquals(t, identities[0].IP.String(), "10.0.2.7")
+}
+
+func TestBroadcastAddress(t *testing.T) {
+	interfaces, e := net.Interfaces()
+	AssertEquals(t, e, nil)
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		address, e := go_eip.BroadcastAddress(iface.Name)
+		AssertEquals(t, e, nil)
+		AssertEquals(t, address, "127.255.255.255")
+		return
+	}
+	t.Skip("no loopback interface")
+}
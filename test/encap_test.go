@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+	"testing"
+)
+
+func TestParseEncapsulation(t *testing.T) {
+	frame := new(bytes.Buffer)
+	binary.Write(frame, binary.LittleEndian, struct {
+		Command, Length uint16
+		Session, Status uint32
+		Context         uint64
+		Options         uint32
+		Handle          uint32
+		Timeout         uint16
+		Count           uint16
+	}{0x70, 0, 0x1234, 0, 7, 0, 0, 0, 3})
+	binary.Write(frame, binary.LittleEndian, struct{ Type, Length uint16 }{0xA1, 4})
+	binary.Write(frame, binary.LittleEndian, uint32(0x20000001))
+	binary.Write(frame, binary.LittleEndian, struct{ Type, Length uint16 }{0x8001, 16})
+	frame.Write([]byte{0x00, 0x02, 0x08, 0xAE, 10, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0, 0})
+	binary.Write(frame, binary.LittleEndian, struct{ Type, Length uint16 }{0xB1, 6})
+	frame.Write([]byte{0x01, 0x00, 0xCC, 0x00, 0x00, 0x00})
+	b := frame.Bytes()
+	binary.LittleEndian.PutUint16(b[2:4], uint16(len(b)-24))
+
+	h, data, e := go_eip.ParseEncapsulation(b)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, h.Command, uint16(0x70))
+	AssertEquals(t, h.SessionHandle, uint32(0x1234))
+	AssertEquals(t, h.Context, uint64(7))
+
+	items, e := go_eip.ParseCPF(data[6:])
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(items), 3)
+	AssertEquals(t, items[1].TypeID, uint16(0x8001))
+	AssertEquals(t, items[2].TypeID, uint16(0xB1))
+	AssertEquals(t, items[2].Data[2], uint8(0xCC))
+
+	id, e := items[0].ConnectionID()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, id, uint32(0x20000001))
+	addr, e := items[1].Sockaddr()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, addr.Family, uint16(2))
+	AssertEquals(t, addr.Port, uint16(2222))
+	AssertEquals(t, addr.IP.String(), "10.0.0.5")
+	_, e = items[2].ConnectionID()
+	AssertEquals(t, e != nil, true)
+	_, e = items[0].Sockaddr()
+	AssertEquals(t, e != nil, true)
+
+	sequenced := go_eip.CPFItem{TypeID: 0x8002, Data: []byte{0x01, 0x00, 0x00, 0x20, 0x07, 0x00, 0x00, 0x00}}
+	id, e = sequenced.ConnectionID()
+	AssertEquals(t, id, uint32(0x20000001))
+	seq, e := sequenced.SequenceNumber()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, seq, uint32(7))
+
+	_, _, e = go_eip.ParseEncapsulation(b[:len(b)-1])
+	AssertEquals(t, e != nil, true)
+	_, e = go_eip.ParseCPF(data[6 : len(data)-1])
+	AssertEquals(t, e != nil, true)
+}
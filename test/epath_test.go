@@ -0,0 +1,84 @@
+package test
+
+import (
+	"bytes"
+	"go_eip/epath"
+	"strings"
+	"testing"
+)
+
+func TestEPathBuild(t *testing.T) {
+	cases := []struct {
+		path []byte
+		want []byte
+	}{
+		{epath.Class(0x8B), []byte{0x20, 0x8B}},
+		{epath.Class(0x300), []byte{0x21, 0x00, 0x00, 0x03}},
+		{epath.Instance(70000), []byte{0x26, 0x00, 0x70, 0x11, 0x01, 0x00}},
+		{epath.Member(300), []byte{0x29, 0x00, 0x2C, 0x01}},
+		{epath.Attribute(7), []byte{0x30, 0x07}},
+		{epath.ConnectionPoint(0x64), []byte{0x2C, 0x64}},
+		{epath.Port(1, []byte{3}), []byte{0x01, 0x03}},
+		{epath.Port(2, []byte("10.0.0.1")), append([]byte{0x12, 0x08}, "10.0.0.1"...)},
+		{epath.Port(2, []byte("10.0.0.10")), append(append([]byte{0x12, 0x09}, "10.0.0.10"...), 0x00)},
+		{epath.Port(20, []byte{5}), []byte{0x0F, 0x14, 0x00, 0x05}},
+		{epath.Symbol("Tag"), []byte{0x91, 0x03, 'T', 'a', 'g', 0x00}},
+		{epath.Symbol("Tags"), []byte{0x91, 0x04, 'T', 'a', 'g', 's'}},
+		{epath.Data([]byte{1, 2, 3}), []byte{0x80, 0x02, 1, 2, 3, 0x00}},
+	}
+	for _, c := range cases {
+		AssertEquals(t, bytes.Equal(c.path, c.want), true)
+	}
+}
+
+func TestEPathParse(t *testing.T) {
+	path := epath.Join(
+		epath.Port(2, []byte("10.0.0.10")),
+		epath.Port(1, []byte{0}),
+		epath.Symbol("Program:Main"),
+		epath.Symbol("Line"),
+		epath.Member(70000),
+		epath.Class(0x6B),
+		epath.Instance(0x1234),
+		epath.Attribute(1),
+		epath.Data([]byte{9}),
+	)
+	segments, e := epath.Parse(path)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(segments), 9)
+	AssertEquals(t, segments[0].Port, uint16(2))
+	AssertEquals(t, string(segments[0].Link), "10.0.0.10")
+	AssertEquals(t, segments[1].Link[0], uint8(0))
+	AssertEquals(t, segments[2].Symbol, "Program:Main")
+	AssertEquals(t, segments[4].Logical, epath.LogicalMember)
+	AssertEquals(t, segments[4].Value, uint32(70000))
+	AssertEquals(t, segments[6].Value, uint32(0x1234))
+	AssertEquals(t, segments[8].Kind, epath.KindData)
+
+	var rebuilt []byte
+	for _, s := range segments {
+		rebuilt = append(rebuilt, s.Bytes()...)
+	}
+	AssertEquals(t, bytes.Equal(rebuilt, path), true)
+	AssertEquals(t, epath.String(epath.Join(epath.Port(1, []byte{0}), epath.Class(2), epath.Instance(1))),
+		"port 1/0, class 2, instance 1")
+
+	_, e = epath.Parse([]byte{0x91, 0x05, 'a'})
+	AssertEquals(t, e != nil, true)
+	_, e = epath.Parse([]byte{0x26, 0x00, 0x01})
+	AssertEquals(t, e != nil, true)
+
+	// an electronic key is a special logical segment
+	key := []byte{0x34, 0x04, 0x01, 0x00, 0x0E, 0x00, 0x5F, 0x00, 0x20, 0x0B}
+	segments, e = epath.Parse(epath.Join(key, epath.Class(2), epath.Instance(1)))
+	AssertEquals(t, e != nil, true)
+	AssertEquals(t, len(segments), 0)
+}
+
+func TestEPathSymbolTooLong(t *testing.T) {
+	AssertEquals(t, len(epath.Symbol(strings.Repeat("a", 255))), 258)
+	defer func() {
+		AssertEquals(t, recover() != nil, true)
+	}()
+	epath.Symbol(strings.Repeat("a", 256))
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"bytes"
+	"go_eip"
+	"testing"
+)
+
+func TestExportImportDiff(t *testing.T) {
+	before := []go_eip.Tag{
+		{TagName: "Speed", InstanceID: 1, DataType: 0xC4, SymbolType: 0xC4, ElementSize: 4},
+		{TagName: "Matrix", InstanceID: 2, DataType: 0xC4, SymbolType: 0x40C4, ElementSize: 4, Dimensions: [3]uint32{4, 5}},
+		{TagName: "Old", InstanceID: 3, DataType: 0xC1, SymbolType: 0xC1, ElementSize: 1},
+	}
+	after := []go_eip.Tag{
+		{TagName: "Speed", InstanceID: 1, DataType: 0xCA, SymbolType: 0xCA, ElementSize: 4},
+		{TagName: "Matrix", InstanceID: 2, DataType: 0xC4, SymbolType: 0x40C4, ElementSize: 4, Dimensions: [3]uint32{4, 5}},
+		{TagName: "New", InstanceID: 4, DataType: 0xC3, SymbolType: 0xC3, ElementSize: 2},
+	}
+
+	for _, format := range []string{"json", "csv"} {
+		var a, b bytes.Buffer
+		AssertEquals(t, go_eip.ExportTags(&a, before, format), nil)
+		AssertEquals(t, go_eip.ExportTags(&b, after, format), nil)
+
+		ra, e := go_eip.ImportTags(&a, format)
+		AssertEquals(t, e, nil)
+		rb, e := go_eip.ImportTags(&b, format)
+		AssertEquals(t, e, nil)
+		AssertEquals(t, ra[1].TypeString(), "DINT[4,5]")
+
+		d := go_eip.DiffTags(ra, rb)
+		AssertEquals(t, len(d.Added), 1)
+		AssertEquals(t, d.Added[0].Name, "New")
+		AssertEquals(t, len(d.Removed), 1)
+		AssertEquals(t, d.Removed[0].Name, "Old")
+		AssertEquals(t, len(d.Retyped), 1)
+		AssertEquals(t, d.Retyped[0].From.Type, "DINT")
+		AssertEquals(t, d.Retyped[0].To.Type, "REAL")
+	}
+}
+
+func TestDiffTagsStructures(t *testing.T) {
+	before := []go_eip.TagRecord{
+		{Name: "Station", Type: "STRUCT", SymbolType: 0x8F01, ElementSize: 40},
+		{Name: "Stations", Type: "STRUCT", SymbolType: 0xAF01, ElementSize: 40, Dimensions: []uint32{4}},
+		{Name: "Flags", Type: "DINT", SymbolType: 0x00C4, ElementSize: 4},
+	}
+	after := []go_eip.TagRecord{
+		{Name: "Station", Type: "STRUCT", SymbolType: 0x8F02, ElementSize: 40},
+		{Name: "Stations", Type: "STRUCT", SymbolType: 0xAF01, ElementSize: 40, Dimensions: []uint32{4}},
+		{Name: "Flags", Type: "DINT", SymbolType: 0x10C4, ElementSize: 4},
+	}
+	AssertEquals(t, before[0].TemplateID(), uint16(0x0F01))
+	AssertEquals(t, before[2].TemplateID(), uint16(0))
+
+	d := go_eip.DiffTags(before, after)
+	AssertEquals(t, len(d.Added), 0)
+	AssertEquals(t, len(d.Removed), 0)
+	AssertEquals(t, len(d.Retyped), 2)
+	AssertEquals(t, d.Retyped[0].Name, "Flags")
+	AssertEquals(t, d.Retyped[1].Name, "Station")
+	AssertEquals(t, d.Retyped[1].To.TemplateID(), uint16(0x0F02))
+
+	after[1].Dimensions = []uint32{5}
+	d = go_eip.DiffTags(before, after)
+	AssertEquals(t, len(d.Retyped), 3)
+}
@@ -0,0 +1,329 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+)
+
+const fakeReplyLimit = 480
+
+type FakeSymbol struct {
+	Instance   uint32
+	Name       string
+	SymbolType uint16
+}
+
+// FakeModule is the identity of a module in a backplane slot.
+type FakeModule struct {
+	DeviceType  uint16
+	ProductCode uint16
+	Major       uint8
+	Minor       uint8
+	Status      uint16
+	Serial      uint32
+	Name        string
+}
+
+// FakeHandler answers requests the fake does not know itself.
+type FakeHandler func(service uint8, path []byte, data []byte) (status uint8, extended []uint16, reply []byte)
+
+// FakeTemplate is a structure definition served from the Template object.
+type FakeTemplate struct {
+	Name    string
+	Handle  uint16
+	Size    uint32
+	Members []go_eip.TemplateMember
+}
+
+// FakePLC answers the encapsulation and CIP requests the client sends with
+// canned replies, so request building and reply parsing can be tested
+// without a controller.
+type FakePLC struct {
+	Symbols   map[string][]FakeSymbol
+	Modules   map[uint8]FakeModule
+	Templates map[uint16]FakeTemplate
+	// RPI is the O->T API in microseconds Forward Open grants, if any.
+	RPI        uint32
+	Interfaces []go_eip.Interface
+	Handler    FakeHandler
+	Requests   int
+}
+
+func (f *FakePLC) Connect() error { return nil }
+func (f *FakePLC) Close() error   { return nil }
+func (f *FakePLC) Verify(request, response []byte) error {
+	return go_eip.NewTCPClientHandler("").Verify(request, response)
+}
+
+func (f *FakePLC) Send(request []byte) ([]byte, error) {
+	f.Requests++
+	command := binary.LittleEndian.Uint16(request[0:2])
+	switch command {
+	case 0x04:
+		return f.encapsulation(request, 0x04, 0, f.listServices()), nil
+	case 0x64:
+		return f.encapsulation(request, 0x64, 0, f.listInterfaces()), nil
+	case 0x65:
+		return f.encapsulation(request, 0x65, 0x12345678, request[24:]), nil
+	case 0x66:
+		return nil, nil
+	case 0x6F:
+		if request[40] == 0x52 {
+			return f.encapsulation(request, 0x6F, binary.LittleEndian.Uint32(request[4:8]), f.unconnectedSend(request[40:])), nil
+		}
+		if request[40] != 0x54 && request[40] != 0x4E {
+			return f.encapsulation(request, 0x6F, binary.LittleEndian.Uint32(request[4:8]), f.unconnected(request[40:])), nil
+		}
+		reply := make([]byte, 16+30)
+		binary.LittleEndian.PutUint16(reply[6:8], 0x02)
+		binary.LittleEndian.PutUint16(reply[12:14], 0xB2)
+		binary.LittleEndian.PutUint16(reply[14:16], 30)
+		reply[16] = request[40] | 0x80
+		binary.LittleEndian.PutUint32(reply[20:24], 0x41000001)
+		binary.LittleEndian.PutUint32(reply[24:28], 0x20000001)
+		binary.LittleEndian.PutUint32(reply[36:40], f.RPI)
+		return f.encapsulation(request, 0x6F, binary.LittleEndian.Uint32(request[4:8]), reply), nil
+	}
+
+	service, path := request[46], request[48:48+int(request[47])*2]
+	status, extended, data := uint8(0x08), []uint16(nil), []byte(nil)
+	switch service {
+	case 0x55:
+		status, data = f.tagList(path)
+	case 0x01:
+		status, data = f.identity(0)
+	case 0x03, 0x4C:
+		if path[0] == 0x20 && path[1] == 0x6C {
+			status, data = f.template(service, path, request[48+len(path):])
+			break
+		}
+		fallthrough
+	default:
+		if f.Handler != nil {
+			status, extended, data = f.Handler(service, path, request[48+len(path):])
+		}
+	}
+
+	reply := new(bytes.Buffer)
+	binary.Write(reply, binary.LittleEndian, struct {
+		InterfaceHandle uint32
+		Timeout         uint16
+		ItemCount       uint16
+		Item1Type       uint16
+		Item1Length     uint16
+		ConnectionID    uint32
+		Item2Type       uint16
+		Item2Length     uint16
+		Sequence        uint16
+		Service         uint8
+		Reserved        uint8
+		Status          uint8
+		ExtStatusSize   uint8
+	}{0, 0, 2, 0xA1, 4, 0x20000001, 0xB1, uint16(6 + 2*len(extended) + len(data)),
+		binary.LittleEndian.Uint16(request[44:46]), service | 0x80, 0, status, uint8(len(extended))})
+	binary.Write(reply, binary.LittleEndian, extended)
+	reply.Write(data)
+	return f.encapsulation(request, 0x70, binary.LittleEndian.Uint32(request[4:8]), reply.Bytes()), nil
+}
+
+func (f *FakePLC) encapsulation(request []byte, command uint16, session uint32, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct {
+		Command uint16
+		Length  uint16
+		Session uint32
+		Status  uint32
+	}{command, uint16(len(data)), session, 0})
+	buf.Write(request[12:24])
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// listServices answers ListServices with the communications service.
+func (f *FakePLC) listServices() []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct {
+		Count, Type, Length, Version, Flags uint16
+		Name                                [16]byte
+	}{1, 0x100, 20, 1, 0x0120, [16]byte{'C', 'o', 'm', 'm', 'u', 'n', 'i', 'c', 'a', 't', 'i', 'o', 'n', 's'}})
+	return buf.Bytes()
+}
+
+// listInterfaces answers ListInterfaces with Interfaces.
+func (f *FakePLC) listInterfaces() []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(f.Interfaces)))
+	for _, i := range f.Interfaces {
+		binary.Write(buf, binary.LittleEndian, struct {
+			Type, Length, Version, Flags uint16
+		}{i.TypeID, uint16(4 + len(i.Data)), i.Version, i.Flags})
+		buf.Write(i.Data)
+	}
+	return buf.Bytes()
+}
+
+// unconnected answers a request sent straight to the device with Handler.
+func (f *FakePLC) unconnected(cip []byte) []byte {
+	path := cip[2 : 2+int(cip[1])*2]
+	status, extended, data := uint8(0x08), []uint16(nil), []byte(nil)
+	if f.Handler != nil {
+		status, extended, data = f.Handler(cip[0], path, cip[2+len(path):])
+	}
+
+	reply := new(bytes.Buffer)
+	binary.Write(reply, binary.LittleEndian, struct {
+		InterfaceHandle uint32
+		Timeout         uint16
+		ItemCount       uint16
+		Item1Type       uint16
+		Item1Length     uint16
+		Item2Type       uint16
+		Item2Length     uint16
+		Service         uint8
+		Reserved        uint8
+		Status          uint8
+		ExtStatusSize   uint8
+	}{0, 0, 2, 0, 0, 0xB2, uint16(4 + 2*len(extended) + len(data)), cip[0] | 0x80, 0, status, uint8(len(extended))})
+	binary.Write(reply, binary.LittleEndian, extended)
+	reply.Write(data)
+	return reply.Bytes()
+}
+
+// unconnectedSend answers Get_Attributes_All on the Identity object of the
+// module in the routed backplane slot.
+func (f *FakePLC) unconnectedSend(cip []byte) []byte {
+	size := int(binary.LittleEndian.Uint16(cip[8:10]))
+	embedded := cip[10 : 10+size]
+	route := cip[10+size+size%2+2:]
+
+	reply := new(bytes.Buffer)
+	binary.Write(reply, binary.LittleEndian, struct {
+		InterfaceHandle uint32
+		Timeout         uint16
+		ItemCount       uint16
+		Item1Type       uint16
+		Item1Length     uint16
+		Item2Type       uint16
+		Item2Length     uint16
+	}{0, 0, 2, 0, 0, 0xB2, 0})
+
+	status, data := f.identity(route[1])
+	if status != 0 {
+		reply.Write([]byte{0xD2, 0, status, 1, 0x04, 0x02})
+	} else {
+		reply.Write([]byte{embedded[0] | 0x80, 0, 0, 0})
+		reply.Write(data)
+	}
+	b := reply.Bytes()
+	binary.LittleEndian.PutUint16(b[14:16], uint16(len(b)-16))
+	return b
+}
+
+func (f *FakePLC) identity(slot uint8) (uint8, []byte) {
+	m, ok := f.Modules[slot]
+	if !ok {
+		return 0x01, nil
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, struct {
+		VendorID     uint16
+		DeviceType   uint16
+		ProductCode  uint16
+		Major, Minor uint8
+		Status       uint16
+		SerialNumber uint32
+		NameLength   uint8
+	}{1, m.DeviceType, m.ProductCode, m.Major, m.Minor, m.Status, m.Serial, uint8(len(m.Name))})
+	buf.WriteString(m.Name)
+	return 0, buf.Bytes()
+}
+
+func (f *FakePLC) tagList(path []byte) (uint8, []byte) {
+	scope := ""
+	if path[0] == 0x91 {
+		scope = string(path[2 : 2+int(path[1])])
+		path = path[2+int(path[1])+int(path[1])%2:]
+	}
+	var start uint32
+	switch path[2] {
+	case 0x24:
+		start = uint32(path[3])
+	case 0x25:
+		start = uint32(binary.LittleEndian.Uint16(path[4:6]))
+	case 0x26:
+		start = binary.LittleEndian.Uint32(path[4:8])
+	}
+
+	buf := new(bytes.Buffer)
+	for _, s := range f.Symbols[scope] {
+		if s.Instance < start {
+			continue
+		}
+		if buf.Len()+23+len(s.Name) > fakeReplyLimit {
+			return 0x06, buf.Bytes()
+		}
+		binary.Write(buf, binary.LittleEndian, struct {
+			Instance       uint32
+			SymbolType     uint16
+			ElementSize    uint16
+			Dimensions     [3]uint32
+			ExternalAccess uint8
+			NameLength     uint16
+		}{s.Instance, s.SymbolType, 4, [3]uint32{}, 0, uint16(len(s.Name))})
+		buf.WriteString(s.Name)
+	}
+	return 0, buf.Bytes()
+}
+
+// template answers Get_Attribute_List and the definition read on the
+// Template object instance in path.
+func (f *FakePLC) template(service uint8, path []byte, data []byte) (uint8, []byte) {
+	var instance uint16
+	switch path[2] {
+	case 0x24:
+		instance = uint16(path[3])
+	case 0x25:
+		instance = binary.LittleEndian.Uint16(path[4:6])
+	}
+	t, ok := f.Templates[instance]
+	if !ok {
+		return 0x05, nil
+	}
+
+	definition := new(bytes.Buffer)
+	for _, m := range t.Members {
+		binary.Write(definition, binary.LittleEndian, struct {
+			Info   uint16
+			Type   uint16
+			Offset uint32
+		}{m.Info, m.Type, m.Offset})
+	}
+	definition.WriteString(t.Name + ";n\x00")
+	for _, m := range t.Members {
+		definition.WriteString(m.Name + "\x00")
+	}
+
+	buf := new(bytes.Buffer)
+	if service == 0x03 {
+		binary.Write(buf, binary.LittleEndian, struct {
+			Count                 uint16
+			DefinitionID, Status1 uint16
+			DefinitionSize        uint32
+			SizeID, Status2       uint16
+			Size                  uint32
+			MembersID, Status3    uint16
+			Members               uint16
+			HandleID, Status4     uint16
+			Handle                uint16
+		}{4, 4, 0, uint32(definition.Len()+23+3) / 4, 5, 0, t.Size, 2, 0, uint16(len(t.Members)), 1, 0, t.Handle})
+		return 0, buf.Bytes()
+	}
+
+	offset := int(binary.LittleEndian.Uint32(data[0:4]))
+	end := offset + int(binary.LittleEndian.Uint16(data[4:6]))
+	if end >= definition.Len() {
+		return 0, definition.Bytes()[offset:]
+	}
+	return 0x06, definition.Bytes()[offset:end]
+}
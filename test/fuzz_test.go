@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+	"go_eip/epath"
+	"testing"
+)
+
+// fuzzPLC opens the session and connection like FakePLC, then answers every
+// request with the fuzzed CIP reply wrapped so that it passes verification.
+type fuzzPLC struct {
+	FakePLC
+	reply []byte
+}
+
+func (f *fuzzPLC) Send(request []byte) ([]byte, error) {
+	command := binary.LittleEndian.Uint16(request[0:2])
+	if command != 0x70 && (command != 0x6F || request[40] == 0x54 || request[40] == 0x4E) {
+		return f.FakePLC.Send(request)
+	}
+
+	items := new(bytes.Buffer)
+	if command == 0x70 {
+		binary.Write(items, binary.LittleEndian, struct {
+			Handle                  uint32
+			Timeout, Count, T1, L1  uint16
+			ConnectionID            uint32
+			T2, L2, SequenceCounter uint16
+		}{0, 0, 2, 0xA1, 4, 0x20000001, 0xB1, uint16(2 + len(f.reply)), binary.LittleEndian.Uint16(request[44:46])})
+	} else {
+		binary.Write(items, binary.LittleEndian, struct {
+			Handle                         uint32
+			Timeout, Count, T1, L1, T2, L2 uint16
+		}{0, 0, 2, 0, 0, 0xB2, uint16(len(f.reply))})
+	}
+	items.Write(f.reply)
+	return f.encapsulation(request, command, binary.LittleEndian.Uint32(request[4:8]), items.Bytes()), nil
+}
+
+func FuzzClientReplies(f *testing.F) {
+	_, identity := (&FakePLC{Modules: map[uint8]FakeModule{0: {0x0E, 0x5F, 32, 11, 0x3060, 1, "1756-L83E/B"}}}).identity(0)
+	f.Add(append([]byte{0x81, 0, 0, 0}, identity...))
+	f.Add([]byte{0xCC, 0, 0, 0, 0xC4, 0, 0x2A, 0, 0, 0})
+	f.Add([]byte{0xCC, 0, 0, 0, 0xD3, 0, 0xFF, 0, 0, 0, 0x01, 0, 0, 0})
+	f.Add([]byte{0xCC, 0, 0, 0, 0xA0, 0x02, 0xCE, 0x0F, 3, 0, 0, 0, 'a', 'b', 'c'})
+	f.Add([]byte{0x8A, 0, 0, 0, 2, 0, 6, 0, 16, 0, 0xCC, 0, 0, 0, 0xC4, 0, 1, 0, 0, 0, 0xCC, 0, 0, 0, 0xC3, 0, 2, 0})
+	f.Add([]byte{0xD5, 0, 0x06, 0, 1, 0, 0, 0, 0xC4, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 'T', 'a', 'g'})
+	f.Add([]byte{0x83, 0, 0, 0, 1, 0, 0x0B, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8})
+	f.Add([]byte{0x83, 0, 0, 0, 1, 0, 0x30, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8})
+
+	f.Fuzz(func(t *testing.T, reply []byte) {
+		plc := &fuzzPLC{}
+		client := go_eip.NewClient(plc, 0)
+		plc.reply = reply
+
+		client.GetIdentity()
+		client.GetModuleIdentity(2)
+		client.GetControllerStatus()
+		client.Read("Tag")
+		client.Read("Tag.3")
+		client.Read("Line[2].Count")
+		client.MultiRead("A", "B")
+		client.ReadBoolArray("Bits[3]", 40)
+		client.GetTagList()
+		client.GetTemplate(1)
+		client.GetPLCTime()
+		client.GetPLCTimeZone()
+		client.GenericMessage(0x0E, 0x01, 1, 7, nil)
+		client.GenericUnconnectedMessage(0x0E, 0xF5, 1, 5, nil, nil)
+	})
+}
+
+func FuzzParseEncapsulation(f *testing.F) {
+	f.Add([]byte{0x70, 0, 8, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0})
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		_, data, err := go_eip.ParseEncapsulation(frame)
+		if err != nil {
+			return
+		}
+		go_eip.ParseCPF(data)
+		if len(data) >= 6 {
+			go_eip.ParseCPF(data[6:])
+		}
+	})
+}
+
+func FuzzEPath(f *testing.F) {
+	f.Add(epath.Join(epath.Port(2, []byte("10.0.0.10")), epath.Symbol("Tag"), epath.Member(70000), epath.Data([]byte{1})))
+	f.Fuzz(func(t *testing.T, path []byte) {
+		segments, err := epath.Parse(path)
+		if err != nil {
+			return
+		}
+		var rebuilt []byte
+		for _, s := range segments {
+			rebuilt = append(rebuilt, s.Bytes()...)
+		}
+		again, err := epath.Parse(rebuilt)
+		if err != nil || len(again) != len(segments) {
+			t.Fatalf("% X re-encoded as % X does not parse back: %v", path, rebuilt, err)
+		}
+	})
+}
+
+func FuzzParseTagPath(f *testing.F) {
+	f.Add("Program:MainProgram.Line[2].Stations[4,1].Count.3")
+	f.Add("Tag[ 1 , 2 ]")
+	f.Fuzz(func(t *testing.T, tag string) {
+		path, err := go_eip.ParseTagPath(tag)
+		if err != nil {
+			return
+		}
+		again, err := go_eip.ParseTagPath(path.String())
+		if err != nil || again.String() != path.String() {
+			t.Fatalf("%q formatted as %q does not parse back: %v", tag, path.String(), err)
+		}
+	})
+}
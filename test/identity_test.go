@@ -0,0 +1,117 @@
+package test
+
+import (
+	"encoding/binary"
+	"go_eip"
+	"testing"
+)
+
+func TestGetIdentity(t *testing.T) {
+	plc := &FakePLC{Modules: map[uint8]FakeModule{
+		0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"},
+		2: {0x0C, 0xA6, 11, 2, 0x0054, 0xBEEF, "1756-EN2T/D"},
+	}}
+	client := go_eip.NewClient(plc, 0)
+
+	id, e := client.GetIdentity()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, id.ProductName, "1756-L83E/B")
+	AssertEquals(t, id.VendorName(), "Rockwell Automation/Allen-Bradley")
+	AssertEquals(t, id.DeviceTypeName(), "Programmable Logic Controller")
+	AssertEquals(t, id.Revision.String(), "32.011")
+	AssertEquals(t, id.Status.ExtendedDeviceStatus(), uint16(6))
+	AssertEquals(t, id.Status.MajorRecoverableFault(), false)
+
+	id, e = client.GetModuleIdentity(2)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, id.ProductName, "1756-EN2T/D")
+	AssertEquals(t, id.SerialNumber, uint32(0xBEEF))
+	AssertEquals(t, id.Status.Configured(), true)
+
+	_, e = client.GetModuleIdentity(5)
+	AssertEquals(t, e != nil, true)
+}
+
+func TestRackScan(t *testing.T) {
+	plc := &FakePLC{Modules: map[uint8]FakeModule{
+		0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"},
+		2: {0x0C, 0xA6, 11, 2, 0x0054, 0xBEEF, "1756-EN2T/D"},
+	}}
+	client := go_eip.NewClient(plc, 0)
+
+	rack, e := client.RackScan(4)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(rack), 4)
+	AssertEquals(t, rack[0].Catalog(), "1756-L83E/B")
+	AssertEquals(t, rack[1].Empty, true)
+	AssertEquals(t, rack[1].Status, uint8(0x01))
+	AssertEquals(t, rack[2].Catalog(), "1756-EN2T/D")
+	AssertEquals(t, rack[2].Identity.Revision.String(), "11.002")
+	AssertEquals(t, rack[3].Empty, true)
+}
+
+// damagedRackPLC cuts the Identity reply of some slots short.
+type damagedRackPLC struct {
+	*FakePLC
+	frame, identity uint8
+}
+
+func (d *damagedRackPLC) Send(request []byte) ([]byte, error) {
+	reply, e := d.FakePLC.Send(request)
+	if e != nil || len(request) <= 40 || request[40] != 0x52 {
+		return reply, e
+	}
+	switch request[len(request)-1] {
+	case d.frame:
+		reply = reply[:42]
+	case d.identity:
+		reply = reply[:50]
+		binary.LittleEndian.PutUint16(reply[38:40], uint16(len(reply)-40))
+	default:
+		return reply, nil
+	}
+	binary.LittleEndian.PutUint16(reply[2:4], uint16(len(reply)-24))
+	return reply, nil
+}
+
+func TestRackScanKeepsGoing(t *testing.T) {
+	plc := &damagedRackPLC{&FakePLC{Modules: map[uint8]FakeModule{
+		0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"},
+		1: {0x0C, 0xA6, 11, 2, 0x0054, 0xBEEF, "1756-EN2T/D"},
+		2: {0x0C, 0xA6, 11, 2, 0x0054, 0xBEEF, "1756-EN2T/D"},
+		4: {0x07, 0x0A, 3, 1, 0x0054, 0xF00D, "1756-IB16/B"},
+	}}, 1, 2}
+	client := go_eip.NewClient(plc, 0)
+
+	rack, e := client.RackScan(5)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(rack), 5)
+	AssertEquals(t, rack[0].Err, nil)
+	AssertEquals(t, rack[1].Empty, false)
+	AssertEquals(t, rack[1].Err != nil, true)
+	AssertEquals(t, rack[2].Empty, false)
+	AssertEquals(t, rack[2].Err != nil, true)
+	AssertEquals(t, rack[3].Empty, true)
+	AssertEquals(t, rack[3].Status, uint8(0x01))
+	AssertEquals(t, rack[3].Err, nil)
+	AssertEquals(t, rack[4].Catalog(), "1756-IB16/B")
+}
+
+func TestGetControllerStatus(t *testing.T) {
+	for _, c := range []struct {
+		status    uint16
+		mode      go_eip.ControllerMode
+		keyswitch go_eip.Keyswitch
+	}{
+		{0x1060, go_eip.ModeRun, go_eip.KeyswitchRun},
+		{0x3070, go_eip.ModeProgram, go_eip.KeyswitchRemote},
+		{0x2070, go_eip.ModeProgram, go_eip.KeyswitchProgram},
+		{0x3474, go_eip.ModeFaulted, go_eip.KeyswitchRemote},
+	} {
+		plc := &FakePLC{Modules: map[uint8]FakeModule{0: {0x0E, 0x5F, 32, 11, c.status, 1, "1756-L83E/B"}}}
+		status, e := go_eip.NewClient(plc, 0).GetControllerStatus()
+		AssertEquals(t, e, nil)
+		AssertEquals(t, status.Mode, c.mode)
+		AssertEquals(t, status.Keyswitch, c.keyswitch)
+	}
+}
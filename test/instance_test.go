@@ -0,0 +1,44 @@
+package test
+
+import (
+	"bytes"
+	"go_eip"
+	"testing"
+)
+
+func TestInstanceMemberAddressing(t *testing.T) {
+	go_eip.GlobalOption.InstanceAddressing = true
+	defer func() { go_eip.GlobalOption.InstanceAddressing = false }()
+
+	var paths [][]byte
+	plc := &FakePLC{
+		Symbols: map[string][]FakeSymbol{"": {{5, "Station", 0x8000 | 0x0123}}},
+		Templates: map[uint16]FakeTemplate{0x0123: {Name: "STATION", Handle: 0x4D2A, Size: 8, Members: []go_eip.TemplateMember{
+			{Name: "Speed", Type: 0xC4, Offset: 0},
+			{Name: "Count", Type: 0xC4, Offset: 4},
+		}}},
+		Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+			if service != 0x52 && service != 0x4C {
+				return 0x08, nil, nil
+			}
+			paths = append(paths, append([]byte{}, path...))
+			return 0, nil, []byte{0xC4, 0x00, 0x2A, 0x00, 0x00, 0x00}
+		},
+	}
+	client := go_eip.NewClient(plc, 0)
+	_, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+
+	v, e := client.Read("Station.Count")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, v, uint32(42))
+	AssertEquals(t, len(paths), 2)
+	for _, path := range paths {
+		AssertEquals(t, bytes.Equal(path, []byte{0x20, 0x6B, 0x24, 0x05, 0x28, 0x01}), true)
+	}
+
+	// members the template does not know stay symbolic
+	paths = nil
+	client.Read("Station.Spare")
+	AssertEquals(t, bytes.Equal(paths[0], []byte{0x20, 0x6B, 0x24, 0x05, 0x91, 0x05, 'S', 'p', 'a', 'r', 'e', 0x00}), true)
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"errors"
+	"go_eip"
+	"sync"
+	"testing"
+	"time"
+)
+
+type flakyPLC struct {
+	FakePLC
+	mu        sync.Mutex
+	keepAlive int
+	down      bool
+}
+
+func (p *flakyPLC) Send(request []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down {
+		return nil, errors.New("connection reset")
+	}
+	return p.FakePLC.Send(request)
+}
+
+func (p *flakyPLC) keepAlives() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keepAlive
+}
+
+func TestKeepAlive(t *testing.T) {
+	go_eip.GlobalOption.KeepAliveInterval = 20 * time.Millisecond
+	defer func() { go_eip.GlobalOption.KeepAliveInterval = 0 }()
+
+	plc := &flakyPLC{}
+	plc.Handler = func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		if service == 0x0E {
+			plc.keepAlive++
+			return 0, nil, []byte{0x60, 0x30}
+		}
+		return 0x08, nil, nil
+	}
+	client := go_eip.NewClient(plc, 0)
+	defer client.Stop()
+
+	time.Sleep(100 * time.Millisecond)
+	AssertEquals(t, plc.keepAlives() >= 2, true)
+	AssertEquals(t, client.Health(), nil)
+
+	plc.mu.Lock()
+	plc.down = true
+	plc.mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	for client.Health() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	AssertEquals(t, client.Health() != nil, true)
+
+	plc.mu.Lock()
+	plc.down = false
+	plc.mu.Unlock()
+	deadline = time.Now().Add(time.Second)
+	for client.Health() != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	AssertEquals(t, client.Health(), nil)
+}
+
+func TestKeepAliveIntervalValidation(t *testing.T) {
+	go_eip.GlobalOption.KeepAliveInterval = 2 * time.Minute
+	defer func() { go_eip.GlobalOption.KeepAliveInterval = 0 }()
+
+	// 0x00201234 us at a multiplier of 32 times out after about 67 seconds
+	client := go_eip.NewClient(&FakePLC{RPI: 0x00201234}, 0)
+	AssertEquals(t, client == nil, true)
+
+	go_eip.GlobalOption.KeepAliveInterval = time.Minute
+	client = go_eip.NewClient(&FakePLC{RPI: 0x00201234}, 0)
+	AssertEquals(t, client != nil, true)
+	client.Stop()
+}
+
+// nopPLC counts the NOPs posted besides the connected keep-alives.
+type nopPLC struct {
+	FakePLC
+	mu        sync.Mutex
+	nops      int
+	connected int
+}
+
+func (p *nopPLC) Send(request []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.FakePLC.Send(request)
+}
+
+func (p *nopPLC) Post(request []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nops++
+	return nil
+}
+
+func (p *nopPLC) counts() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nops, p.connected
+}
+
+func TestKeepAliveNOPKeepsConnection(t *testing.T) {
+	go_eip.GlobalOption.KeepAliveInterval = 20 * time.Millisecond
+	go_eip.GlobalOption.KeepAliveMode = go_eip.KeepAliveNOP
+	defer func() {
+		go_eip.GlobalOption.KeepAliveInterval = 0
+		go_eip.GlobalOption.KeepAliveMode = go_eip.KeepAliveConnected
+	}()
+
+	// the connection times out after 32 ms, so NOPs alone would lose it
+	plc := &nopPLC{FakePLC: FakePLC{RPI: 1000}}
+	plc.Handler = func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		if service == 0x0E {
+			plc.connected++
+			return 0, nil, []byte{0x60, 0x30}
+		}
+		return 0x08, nil, nil
+	}
+	client := go_eip.NewClient(plc, 0)
+	defer client.Stop()
+
+	time.Sleep(150 * time.Millisecond)
+	nops, connected := plc.counts()
+	AssertEquals(t, nops > 0, true)
+	AssertEquals(t, connected > 0, true)
+	AssertEquals(t, client.Health(), nil)
+}
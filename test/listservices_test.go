@@ -0,0 +1,81 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListServicesUDP(t *testing.T) {
+	responder, e := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	AssertEquals(t, e, nil)
+	defer responder.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := responder.ReadFromUDP(buf)
+			if err != nil || n < 24 {
+				return
+			}
+			reply := new(bytes.Buffer)
+			binary.Write(reply, binary.LittleEndian, struct {
+				Command uint16
+				Length  uint16
+				Session uint32
+				Status  uint32
+				Context uint64
+				Options uint32
+			}{binary.LittleEndian.Uint16(buf[0:2]), 0, 0, 0, 0, 0})
+			switch binary.LittleEndian.Uint16(buf[0:2]) {
+			case 0x04:
+				binary.Write(reply, binary.LittleEndian, struct {
+					Count, Type, Length, Version, Flags uint16
+					Name                                [16]byte
+				}{1, 0x100, 20, 1, 0x0120, [16]byte{'C', 'o', 'm', 'm', 'u', 'n', 'i', 'c', 'a', 't', 'i', 'o', 'n', 's'}})
+			case 0x64:
+				binary.Write(reply, binary.LittleEndian, uint16(0))
+			}
+			b := reply.Bytes()
+			binary.LittleEndian.PutUint16(b[2:4], uint16(len(b)-24))
+			responder.WriteToUDP(b, from)
+		}
+	}()
+
+	services, e := go_eip.ListServicesUDP(responder.LocalAddr().String(), time.Second)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(services), 1)
+	AssertEquals(t, services[0].Name, "Communications")
+	AssertEquals(t, services[0].SupportsCIPOverTCP(), true)
+	AssertEquals(t, services[0].SupportsClass01UDP(), true)
+
+	interfaces, e := go_eip.ListInterfacesUDP(responder.LocalAddr().String(), time.Second)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(interfaces), 0)
+}
+
+func TestListServices(t *testing.T) {
+	plc := &FakePLC{Interfaces: []go_eip.Interface{{TypeID: 0x8100, Version: 1, Flags: 0x0020, Data: []byte{1, 2}}}}
+	client := go_eip.NewClient(plc, 0)
+
+	services, e := client.ListServices()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(services), 1)
+	AssertEquals(t, services[0].TypeID, uint16(0x100))
+	AssertEquals(t, services[0].Version, uint16(1))
+	AssertEquals(t, services[0].Name, "Communications")
+	AssertEquals(t, services[0].SupportsCIPOverTCP(), true)
+	AssertEquals(t, services[0].SupportsClass01UDP(), true)
+
+	interfaces, e := client.ListInterfaces()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(interfaces), 1)
+	AssertEquals(t, interfaces[0].TypeID, uint16(0x8100))
+	AssertEquals(t, interfaces[0].Version, uint16(1))
+	AssertEquals(t, interfaces[0].SupportsCIPOverTCP(), true)
+	AssertEquals(t, interfaces[0].SupportsClass01UDP(), false)
+	AssertEquals(t, bytes.Equal(interfaces[0].Data, []byte{1, 2}), true)
+}
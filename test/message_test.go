@@ -0,0 +1,105 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go_eip"
+	"testing"
+	"time"
+)
+
+func TestGenericMessage(t *testing.T) {
+	var paths [][]byte
+	plc := &FakePLC{Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		paths = append(paths, append([]byte{}, path...))
+		switch service {
+		case 0x0E:
+			return 0, nil, []byte{0x2A, 0x00}
+		case 0x10:
+			return 0x0E, []uint16{0x0102}, nil
+		}
+		return 0x08, nil, nil
+	}}
+	client := go_eip.NewClient(plc, 0)
+
+	data, status, e := client.GenericMessage(0x0E, 0x01, 1, 7, nil)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, bytes.Equal(data, []byte{0x2A, 0x00}), true)
+	AssertEquals(t, status.General, uint8(0))
+	AssertEquals(t, status.Service, uint8(0x8E))
+	AssertEquals(t, bytes.Equal(paths[0], []byte{0x20, 0x01, 0x24, 0x01, 0x30, 0x07}), true)
+
+	_, status, e = client.GenericMessage(0x10, 0x300, 70000, 0, []byte{1})
+	AssertEquals(t, status.General, uint8(0x0E))
+	AssertEquals(t, len(status.Extended), 1)
+	AssertEquals(t, status.Extended[0], uint16(0x0102))
+	AssertEquals(t, e.(*go_eip.CIPError).Status.General, uint8(0x0E))
+	AssertEquals(t, bytes.Equal(paths[1], []byte{0x21, 0x00, 0x00, 0x03, 0x26, 0x00, 0x70, 0x11, 0x01, 0x00}), true)
+
+	data, status, e = client.GenericUnconnectedMessage(0x0E, 0xF5, 1, 0x105, nil, nil)
+	AssertEquals(t, e, nil)
+	AssertEquals(t, bytes.Equal(data, []byte{0x2A, 0x00}), true)
+	AssertEquals(t, bytes.Equal(paths[2], []byte{0x20, 0xF5, 0x24, 0x01, 0x31, 0x00, 0x05, 0x01}), true)
+}
+
+func TestPLCTime(t *testing.T) {
+	var clock, local, zone, dst, apply []byte
+	plc := &FakePLC{Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		if !bytes.Equal(path, []byte{0x20, 0x8B, 0x24, 0x01}) {
+			return 0x05, nil, nil
+		}
+		values := map[uint16]*[]byte{0x06: &clock, 0x0B: &local, 0x07: &zone, 0x09: &dst, 0x0A: &apply}
+		sizes := map[uint16]int{0x06: 8, 0x0B: 8, 0x07: 2, 0x09: 2, 0x0A: 1}
+		count := int(binary.LittleEndian.Uint16(data[0:2]))
+		reply := new(bytes.Buffer)
+		binary.Write(reply, binary.LittleEndian, uint16(count))
+		data = data[2:]
+		for i := 0; i < count; i++ {
+			id := binary.LittleEndian.Uint16(data[0:2])
+			data = data[2:]
+			binary.Write(reply, binary.LittleEndian, struct{ ID, Status uint16 }{id, 0})
+			if service == 0x04 {
+				*values[id] = append([]byte{}, data[:sizes[id]]...)
+				data = data[sizes[id]:]
+			} else {
+				reply.Write(*values[id])
+			}
+		}
+		return 0, nil, reply.Bytes()
+	}}
+	client := go_eip.NewClient(plc, 0)
+
+	want := time.Date(2020, 2, 29, 12, 30, 0, 0, time.UTC)
+	AssertEquals(t, client.SetPLCTime(want), nil)
+	got, e := client.GetPLCTime()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, got.Equal(want), true)
+
+	AssertEquals(t, client.SetPLCTimeZone(go_eip.PLCTimeZone{
+		Offset:        -5 * time.Hour,
+		DSTAdjustment: time.Hour,
+		ApplyDST:      true,
+	}), nil)
+	z, e := client.GetPLCTimeZone()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, z.Offset, -5*time.Hour)
+	AssertEquals(t, z.ApplyDST, true)
+
+	// the local clock is its own attribute
+	AssertEquals(t, client.SetPLCLocalTime(want), nil)
+	wall := time.Date(2020, 2, 29, 8, 30, 0, 0, time.UTC)
+	AssertEquals(t, binary.LittleEndian.Uint64(local), uint64(wall.UnixNano()/1e3))
+	AssertEquals(t, binary.LittleEndian.Uint64(clock), uint64(want.UnixNano()/1e3))
+	got, e = client.GetPLCLocalTime()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, got.Hour(), 8)
+	AssertEquals(t, got.Equal(want), true)
+
+	binary.LittleEndian.PutUint64(clock, 0)
+	got, e = client.GetPLCLocalTime()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, got.Equal(want), true)
+	got, e = client.GetPLCTime()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, got.Unix(), int64(0))
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"bytes"
+	"go_eip"
+	"testing"
+)
+
+func TestMultiReadBoolArray(t *testing.T) {
+	var request []byte
+	plc := &FakePLC{Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		switch service {
+		case 0x52:
+			// only the DWORD holding bit 37 exists
+			if path[len(path)-1] == 1 {
+				return 0, nil, []byte{0xD3, 0x00, 0x00, 0x00, 0x00, 0x00}
+			}
+			return 0x05, nil, nil
+		case 0x0A:
+			request = append([]byte{}, data...)
+			return 0, nil, []byte{0x01, 0x00, 0x04, 0x00, 0xCC, 0x00, 0x00, 0x00, 0xD3, 0x00, 0x20, 0x00, 0x00, 0x00}
+		}
+		return 0x08, nil, nil
+	}}
+	client := go_eip.NewClient(plc, 0)
+
+	reply, e := client.MultiRead("bools[37]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, reply["bools[37]"], true)
+	AssertEquals(t, bytes.HasSuffix(request, []byte{0x91, 0x05, 'b', 'o', 'o', 'l', 's', 0x00, 0x28, 0x01, 0x01, 0x00}), true)
+
+	_, e = client.ReadBoolArray("bools[0]", -1)
+	AssertEquals(t, e != nil, true)
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"bytes"
+	"go_eip"
+	"strings"
+	"testing"
+)
+
+func TestWriteCustomString(t *testing.T) {
+	var writes [][]byte
+	plc := &FakePLC{
+		Symbols: map[string][]FakeSymbol{"": {{7, "Label", 0x8000 | 0x0FCE}}},
+		Templates: map[uint16]FakeTemplate{0x0FCE: {Name: "STRING_20", Handle: 0x3A2F, Size: 24, Members: []go_eip.TemplateMember{
+			{Name: "LEN", Type: 0xC4, Offset: 0},
+			{Name: "DATA", Info: 20, Type: 0x20C2, Offset: 4},
+		}}},
+		Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+			if service == 0x4D {
+				writes = append(writes, append(append([]byte{}, path...), data...))
+				return 0, nil, nil
+			}
+			return 0x08, nil, nil
+		},
+	}
+	client := go_eip.NewClient(plc, 0)
+	_, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+
+	AssertEquals(t, client.Write("Label", "hello"), nil)
+	AssertEquals(t, len(writes), 1)
+	want := []byte{0x91, 0x05, 'L', 'a', 'b', 'e', 'l', 0x00, 0xA0, 0x02, 0x2F, 0x3A, 0x01, 0x00}
+	structure := make([]byte, 24)
+	structure[0] = 5
+	copy(structure[4:], "hello")
+	want = append(want, structure...)
+	AssertEquals(t, bytes.Equal(writes[0], want), true)
+
+	e = client.Write("Label", strings.Repeat("x", 21))
+	AssertEquals(t, e != nil && strings.Contains(e.Error(), "do not fit"), true)
+	AssertEquals(t, len(writes), 1)
+}
+
+func TestWriteStringWithoutTemplate(t *testing.T) {
+	handle := uint16(0x0FCE)
+	var writes int
+	plc := &FakePLC{
+		Symbols: map[string][]FakeSymbol{"": {{3, "Motor", 0x8000 | 0x0321}}},
+		Templates: map[uint16]FakeTemplate{0x0321: {Name: "MOTOR", Handle: 0x1111, Size: 8, Members: []go_eip.TemplateMember{
+			{Name: "Speed", Type: 0xC4, Offset: 0},
+			{Name: "Mode", Type: 0xC4, Offset: 4},
+		}}},
+		Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+			switch service {
+			case 0x52, 0x4C:
+				// a partial read returns the handle but not the whole string
+				return 0, nil, []byte{0xA0, 0x02, byte(handle), byte(handle >> 8), 0x00, 0x00, 0x00, 0x00, 0, 0}
+			case 0x4D:
+				writes++
+				return 0, nil, nil
+			}
+			return 0x08, nil, nil
+		},
+	}
+	client := go_eip.NewClient(plc, 0)
+
+	// the stock STRING holds 82 characters, not the 84 its padding suggests
+	AssertEquals(t, client.Write("Name", strings.Repeat("x", 82)), nil)
+	e := client.Write("Name", strings.Repeat("x", 83))
+	AssertEquals(t, e != nil && strings.Contains(e.Error(), "do not fit"), true)
+
+	handle = 0x2222
+	e = client.Write("Other", "x")
+	AssertEquals(t, e != nil && strings.Contains(e.Error(), "not known"), true)
+
+	_, e = client.GetTagList()
+	AssertEquals(t, e, nil)
+	e = client.Write("Motor", "x")
+	AssertEquals(t, e != nil && strings.Contains(e.Error(), "MOTOR, not a string"), true)
+	AssertEquals(t, writes, 1)
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go_eip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTagCachePerClient(t *testing.T) {
+	signature := func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		if service == 0x03 {
+			return 0, nil, []byte{0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2A, 0x00}
+		}
+		return 0x08, nil, nil
+	}
+	a := go_eip.NewClient(&FakePLC{Symbols: map[string][]FakeSymbol{"": {{1, "OnlyA", 0xC4}}}, Handler: signature}, 0)
+	b := go_eip.NewClient(&FakePLC{Symbols: map[string][]FakeSymbol{"": {{1, "OnlyB", 0xCA}}}, Handler: signature}, 0)
+	_, e := a.GetTagList()
+	AssertEquals(t, e, nil)
+	_, e = b.GetTagList()
+	AssertEquals(t, e, nil)
+
+	filename := filepath.Join(t.TempDir(), "b.json")
+	AssertEquals(t, b.SaveTagCache(filename), nil)
+	data, e := os.ReadFile(filename)
+	AssertEquals(t, e, nil)
+	var cache struct {
+		DataTypes map[string]uint8 `json:"dataTypes"`
+	}
+	AssertEquals(t, json.Unmarshal(data, &cache), nil)
+	AssertEquals(t, len(cache.DataTypes), 1)
+	AssertEquals(t, cache.DataTypes["OnlyB"], uint8(0xCA))
+}
+
+// projectPLC answers the Controller object change counters with signature
+// and counts the partial reads that resolve tag types.
+type projectPLC struct {
+	FakePLC
+	signature []byte
+	partial   int
+}
+
+func newProjectPLC() *projectPLC {
+	p := &projectPLC{signature: []byte{0x05, 0x00, 0x01, 0x00}}
+	p.Handler = func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		switch {
+		case service == 0x03 && bytes.Equal(path, []byte{0x20, 0xAC, 0x24, 0x01}):
+			return 0, nil, p.signature
+		case service == 0x52:
+			p.partial++
+			return 0, nil, []byte{0xC4, 0x00, 0x00, 0x00, 0x00, 0x00}
+		case service == 0x4C:
+			return 0, nil, []byte{0xC4, 0x00, 0x2A, 0x00, 0x00, 0x00}
+		}
+		return 0x08, nil, nil
+	}
+	return p
+}
+
+func TestProjectChangeClearsCache(t *testing.T) {
+	go_eip.GlobalOption.ProjectCheckInterval = time.Nanosecond
+	defer func() { go_eip.GlobalOption.ProjectCheckInterval = 10 * time.Second }()
+
+	plc := newProjectPLC()
+	client := go_eip.NewClient(plc, 0)
+	_, e := client.Read("Count")
+	AssertEquals(t, e, nil)
+	client.Read("Count")
+	AssertEquals(t, plc.partial, 1)
+
+	// a download moves the change counters and the next read relearns the type
+	plc.signature = []byte{0x05, 0x00, 0x02, 0x00}
+	client.Read("Count")
+	AssertEquals(t, plc.partial, 2)
+	changed, e := client.ProjectChanged()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, changed, false)
+}
+
+func TestTagCacheRoundTrip(t *testing.T) {
+	plc := newProjectPLC()
+	filename := filepath.Join(t.TempDir(), "cache.json")
+
+	a := go_eip.NewClient(plc, 0)
+	a.Read("Count")
+	AssertEquals(t, a.SaveTagCache(filename), nil)
+	AssertEquals(t, plc.partial, 1)
+
+	b := go_eip.NewClient(plc, 0)
+	AssertEquals(t, b.LoadTagCache(filename), nil)
+	v, e := b.Read("Count")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, v, uint32(42))
+	AssertEquals(t, plc.partial, 1)
+
+	plc.signature = []byte{0x05, 0x00, 0x02, 0x00}
+	c := go_eip.NewClient(plc, 0)
+	AssertEquals(t, c.LoadTagCache(filename), go_eip.ErrTagCacheStale)
+	c.Read("Count")
+	AssertEquals(t, plc.partial, 2)
+}
+
+func TestSaveTagCacheAfterDownload(t *testing.T) {
+	plc := newProjectPLC()
+	client := go_eip.NewClient(plc, 0)
+	client.Read("Count")
+
+	// the cache was learned before the download, so it must not be saved
+	// under the new signature
+	plc.signature = []byte{0x05, 0x00, 0x02, 0x00}
+	filename := filepath.Join(t.TempDir(), "cache.json")
+	AssertEquals(t, client.SaveTagCache(filename), nil)
+	data, e := os.ReadFile(filename)
+	AssertEquals(t, e, nil)
+	var cache struct {
+		Signature []byte           `json:"signature"`
+		DataTypes map[string]uint8 `json:"dataTypes"`
+	}
+	AssertEquals(t, json.Unmarshal(data, &cache), nil)
+	AssertEquals(t, bytes.Equal(cache.Signature, plc.signature), true)
+	AssertEquals(t, len(cache.DataTypes), 0)
+}
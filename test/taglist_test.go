@@ -0,0 +1,39 @@
+package test
+
+import (
+	"fmt"
+	"go_eip"
+	"testing"
+)
+
+func TestTagListPaging(t *testing.T) {
+	plc := &FakePLC{Symbols: map[string][]FakeSymbol{}}
+	for i := 0; i < 20000; i++ {
+		plc.Symbols[""] = append(plc.Symbols[""], FakeSymbol{uint32(i*3 + 1), fmt.Sprintf("Tag_%d", i), 0xC4})
+	}
+	plc.Symbols[""] = append(plc.Symbols[""], FakeSymbol{70000, "Program:MainProgram", 0x1068})
+	for i := 0; i < 500; i++ {
+		plc.Symbols["Program:MainProgram"] = append(plc.Symbols["Program:MainProgram"],
+			FakeSymbol{uint32(i + 1), fmt.Sprintf("Local_%d", i), 0xC3})
+	}
+
+	client := go_eip.NewClient(plc, 0)
+	tags, e := client.GetTagList()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(tags), 20000+1+500)
+
+	names := make(map[string]bool)
+	for _, tag := range tags {
+		if names[tag.TagName] {
+			t.Fatalf("duplicate tag %s", tag.TagName)
+		}
+		names[tag.TagName] = true
+	}
+	AssertEquals(t, names["Tag_19999"], true)
+	AssertEquals(t, names["Program:MainProgram.Local_499"], true)
+
+	programs, e := client.ListPrograms()
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(programs), 1)
+	AssertEquals(t, programs[0], "Program:MainProgram")
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"go_eip"
+	"strings"
+	"testing"
+)
+
+func TestParseTagPath(t *testing.T) {
+	p, e := go_eip.ParseTagPath("Program:MainProgram.Line[2].Stations[4, 1].Count.31")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, p.Program, "MainProgram")
+	AssertEquals(t, len(p.Segments), 3)
+	AssertEquals(t, p.Segments[0].Name, "Line")
+	AssertEquals(t, p.Segments[1].Indices[0], 4)
+	AssertEquals(t, p.Segments[1].Indices[1], 1)
+	AssertEquals(t, p.Last().Name, "Count")
+	AssertEquals(t, p.Bit, 31)
+	AssertEquals(t, p.String(), "Program:MainProgram.Line[2].Stations[4,1].Count.31")
+
+	p, e = go_eip.ParseTagPath("Local:1:I.Data[3]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, p.Program, "")
+	AssertEquals(t, p.Segments[0].Name, "Local:1:I")
+	AssertEquals(t, p.HasBit(), false)
+
+	p, e = go_eip.ParseTagPath("Matrix[2,3,4]")
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(p.Last().Indices), 3)
+	AssertEquals(t, p.Element(7).String(), "Matrix[7]")
+}
+
+func TestParseTagPathErrors(t *testing.T) {
+	for _, tag := range []string{
+		"",
+		"Program:Main",
+		"Program:Main.",
+		"1abc",
+		"a..b",
+		"a.",
+		"a[",
+		"a[1",
+		"a[x]",
+		"a[1,2,3,4]",
+		"a.64",
+		"a.3.b",
+		"a.3x",
+		"a b",
+		strings.Repeat("a", 256),
+		"Program:" + strings.Repeat("p", 248) + ".a",
+	} {
+		_, e := go_eip.ParseTagPath(tag)
+		if _, ok := e.(*go_eip.TagSyntaxError); !ok {
+			t.Errorf("ParseTagPath(%q): expected a syntax error, got %v", tag, e)
+		}
+	}
+}
+
+func TestLongNames(t *testing.T) {
+	p, e := go_eip.ParseTagPath("Program:" + strings.Repeat("p", 247) + "." + strings.Repeat("a", 255))
+	AssertEquals(t, e, nil)
+	AssertEquals(t, len(p.Program), 247)
+
+	client := go_eip.NewClient(&FakePLC{}, 0)
+	_, e = client.ListTags(strings.Repeat("p", 248), false)
+	AssertEquals(t, e != nil, true)
+	_, e = client.Read("Program:" + strings.Repeat("p", 248) + ".a")
+	AssertEquals(t, e != nil, true)
+}
@@ -0,0 +1,17 @@
+package test
+
+import (
+	"go_eip"
+	"testing"
+)
+
+func TestSendWithoutConnection(t *testing.T) {
+	handler := go_eip.NewTCPClientHandler("127.0.0.1")
+	_, e := handler.Send([]byte{0x00})
+	AssertEquals(t, e != nil, true)
+
+	handler.Close()
+	_, e = handler.Send([]byte{0x00})
+	AssertEquals(t, e != nil, true)
+	AssertEquals(t, handler.Post([]byte{0x00}) != nil, true)
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"encoding/binary"
+	"go_eip"
+	"strings"
+	"testing"
+)
+
+type tamperPLC struct {
+	*FakePLC
+	tamper func(reply []byte)
+}
+
+func (p *tamperPLC) Send(request []byte) ([]byte, error) {
+	reply, err := p.FakePLC.Send(request)
+	if p.tamper != nil && len(reply) > 0 {
+		p.tamper(reply)
+	}
+	return reply, err
+}
+
+func TestVerifyReply(t *testing.T) {
+	plc := &tamperPLC{FakePLC: &FakePLC{Modules: map[uint8]FakeModule{
+		0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"},
+		2: {0x0C, 0xA6, 11, 2, 0x0054, 0xBEEF, "1756-EN2T/D"},
+	}}}
+	client := go_eip.NewClient(plc, 0)
+	_, e := client.GetIdentity()
+	AssertEquals(t, e, nil)
+	_, e = client.GetModuleIdentity(2)
+	AssertEquals(t, e, nil)
+
+	cases := []struct {
+		tamper func(reply []byte)
+		want   string
+	}{
+		{func(r []byte) { r[0] = 0x6F }, "command"},
+		{func(r []byte) { r[4]++ }, "session"},
+		{func(r []byte) { r[12]++ }, "sender context"},
+		{func(r []byte) { r[36]++ }, "connection"},
+		{func(r []byte) { r[44]++ }, "sequence count"},
+		{func(r []byte) { r[46] = 0x81 | 0x02 }, "reply service"},
+		{func(r []byte) { binary.LittleEndian.PutUint32(r[8:12], 0x64) }, "invalid session handle"},
+	}
+	for _, c := range cases {
+		plc.tamper = c.tamper
+		_, e = client.GetIdentity()
+		AssertEquals(t, e != nil && strings.Contains(e.Error(), c.want), true)
+	}
+
+	plc.tamper = func(r []byte) { r[40] = 0x8E }
+	_, e = client.GetModuleIdentity(2)
+	AssertEquals(t, e != nil && strings.Contains(e.Error(), "reply service"), true)
+	plc.tamper = nil
+	_, e = client.GetModuleIdentity(5)
+	AssertEquals(t, e != nil && !strings.Contains(e.Error(), "reply service"), true)
+}
+
+func TestVerifyConnectionPerClient(t *testing.T) {
+	modules := map[uint8]FakeModule{0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"}}
+	a := go_eip.NewClient(&FakePLC{Modules: modules}, 0)
+	other := &tamperPLC{FakePLC: &FakePLC{Modules: modules}, tamper: func(r []byte) {
+		switch {
+		case r[0] == 0x6F && r[40] == 0xD4:
+			binary.LittleEndian.PutUint32(r[48:52], 0x20000002)
+		case r[0] == 0x70:
+			binary.LittleEndian.PutUint32(r[36:40], 0x20000002)
+		}
+	}}
+	b := go_eip.NewClient(other, 0)
+
+	_, e := a.GetIdentity()
+	AssertEquals(t, e, nil)
+	_, e = b.GetIdentity()
+	AssertEquals(t, e, nil)
+}
+
+type silentPLC struct {
+	FakePLC
+	silent bool
+}
+
+func (p *silentPLC) Send(request []byte) ([]byte, error) {
+	if p.silent {
+		return nil, nil
+	}
+	return p.FakePLC.Send(request)
+}
+
+func TestVerifyEmptyReply(t *testing.T) {
+	plc := &silentPLC{FakePLC: FakePLC{Modules: map[uint8]FakeModule{0: {0x0E, 0x5F, 32, 11, 0x3060, 0xC0FFEE, "1756-L83E/B"}}}}
+	client := go_eip.NewClient(plc, 0)
+	plc.silent = true
+	_, e := client.GetIdentity()
+	AssertEquals(t, e != nil && strings.Contains(e.Error(), "no reply"), true)
+
+	handler := go_eip.NewTCPClientHandler("")
+	nop := make([]byte, 24)
+	AssertEquals(t, handler.Verify(nop, nil), nil)
+	unregister := make([]byte, 24)
+	unregister[0] = 0x66
+	AssertEquals(t, handler.Verify(unregister, nil), nil)
+}
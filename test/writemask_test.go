@@ -0,0 +1,34 @@
+package test
+
+import (
+	"bytes"
+	"go_eip"
+	"testing"
+)
+
+func TestWriteMaskWidth(t *testing.T) {
+	var writes [][]byte
+	plc := &FakePLC{Handler: func(service uint8, path []byte, data []byte) (uint8, []uint16, []byte) {
+		switch service {
+		case 0x52:
+			return 0, nil, []byte{0xC2, 0x00, 0x00}
+		case 0x4E:
+			writes = append(writes, append([]byte{}, data...))
+			return 0, nil, nil
+		}
+		return 0x08, nil, nil
+	}}
+	client := go_eip.NewClient(plc, 0)
+
+	AssertEquals(t, client.WriteBits("maskSint", map[int]bool{12: false}) != nil, true)
+	AssertEquals(t, client.WriteBits("maskSint", map[int]bool{8: true}) != nil, true)
+	AssertEquals(t, client.WriteMasked("maskSint", 0, 0xFFFFFFFFFFFFEFFF) != nil, true)
+	AssertEquals(t, client.WriteMasked("maskSint", 0x100, 0xFF) != nil, true)
+	AssertEquals(t, len(writes), 0)
+
+	AssertEquals(t, client.WriteBits("maskSint", map[int]bool{0: false, 7: true}), nil)
+	AssertEquals(t, client.WriteMasked("maskSint", 0x01, 0x0F), nil)
+	AssertEquals(t, len(writes), 2)
+	AssertEquals(t, bytes.Equal(writes[0], []byte{0x01, 0x00, 0x80, 0xFE}), true)
+	AssertEquals(t, bytes.Equal(writes[1], []byte{0x01, 0x00, 0x01, 0x0F}), true)
+}